@@ -0,0 +1,90 @@
+// Package main implements groupcache-exporter, a sidecar that polls the JSON stats
+// endpoint (package jsonstats) of remote applications and re-exports them as
+// Prometheus metrics, labeled with instance.
+//
+// Targets given with -targets are polled periodically and exposed on /metrics.
+// Any target can also be scraped on demand, Prometheus multi-target style:
+//
+//	/probe?target=http://app:8080/groupcache/stats
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// InstanceLabel identifies the remote application.
+const InstanceLabel = "instance"
+
+type config struct {
+	listen      string
+	targets     []string
+	interval    time.Duration
+	timeout     time.Duration
+	namespace   string
+	groupLabels []string
+	debug       bool
+	selfMetrics bool
+}
+
+func main() {
+
+	var cfg config
+	var targets, groupLabels string
+
+	flag.StringVar(&cfg.listen, "listen", ":9111", "listen address")
+	flag.StringVar(&targets, "targets", "", "comma-separated list of remote JSON stats URLs polled for /metrics")
+	flag.DurationVar(&cfg.interval, "interval", 15*time.Second, "polling interval")
+	flag.DurationVar(&cfg.timeout, "timeout", 5*time.Second, "timeout for fetching a target")
+	flag.StringVar(&cfg.namespace, "namespace", "", "metrics namespace")
+	flag.StringVar(&groupLabels, "groupLabels", "", "comma-separated list of remote group labels to export, like workspace")
+	flag.BoolVar(&cfg.debug, "debug", false, "enable debug")
+	flag.BoolVar(&cfg.selfMetrics, "selfMetrics", false, "enable exporter self metrics")
+	flag.Parse()
+
+	cfg.targets = splitList(targets)
+	cfg.groupLabels = splitList(groupLabels)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	p := newPoller(cfg, &http.Client{Timeout: cfg.timeout})
+	prometheus.MustRegister(p.upCollector(), newExporter(cfg, p.listGroups))
+	go p.run(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})))
+	mux.Handle("/probe", newProbeHandler(cfg, &http.Client{Timeout: cfg.timeout}))
+
+	server := &http.Server{Addr: cfg.listen, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	log.Printf("groupcache-exporter: listening on %s, targets: %v", cfg.listen, cfg.targets)
+	err := server.ListenAndServe()
+	log.Printf("groupcache-exporter: exited: %v", err)
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
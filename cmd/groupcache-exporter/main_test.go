@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/udhos/groupcache_exporter"
+	"github.com/udhos/groupcache_exporter/jsonstats"
+)
+
+type fakeGroup struct {
+	name  string
+	stats groupcache_exporter.Stats
+}
+
+func (g *fakeGroup) Collect() groupcache_exporter.Stats { return g.stats }
+
+func (g *fakeGroup) Name() string { return g.name }
+
+// remoteApp starts a stand-in application serving JSON stats for one group.
+func remoteApp(t *testing.T, gets int64) *httptest.Server {
+	t.Helper()
+	g := &fakeGroup{name: "group1"}
+	g.stats.Group.CounterGets = gets
+	h := jsonstats.NewHandler(jsonstats.Options{
+		ListGroups: func() []groupcache_exporter.GroupStatistics {
+			return []groupcache_exporter.GroupStatistics{g}
+		},
+	})
+	s := httptest.NewServer(h)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// go test -count 1 -run '^TestPoller$' ./...
+func TestPoller(t *testing.T) {
+	app1 := remoteApp(t, 10)
+	app2 := remoteApp(t, 20)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	cfg := config{targets: []string{app1.URL, app2.URL, down.URL}}
+	p := newPoller(cfg, http.DefaultClient)
+	p.pollOnce(context.Background())
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(p.upCollector(), newExporter(cfg, p.listGroups))
+
+	expected := `
+# HELP groupcache_gets_total Count of cache gets (including from peers)
+# TYPE groupcache_gets_total counter
+groupcache_gets_total{group="group1",instance="` + app1.URL + `"} 10
+groupcache_gets_total{group="group1",instance="` + app2.URL + `"} 20
+# HELP groupcache_exporter_target_up Whether the latest poll of the target succeeded
+# TYPE groupcache_exporter_target_up gauge
+groupcache_exporter_target_up{instance="` + app1.URL + `"} 1
+groupcache_exporter_target_up{instance="` + app2.URL + `"} 1
+groupcache_exporter_target_up{instance="` + down.URL + `"} 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"groupcache_gets_total", "groupcache_exporter_target_up"); err != nil {
+		t.Error(err)
+	}
+}
+
+// go test -count 1 -run '^TestProbe$' ./...
+func TestProbe(t *testing.T) {
+	app := remoteApp(t, 7)
+
+	probe := httptest.NewServer(newProbeHandler(config{}, http.DefaultClient))
+	defer probe.Close()
+
+	body := func(query string) (int, string) {
+		resp, err := http.Get(probe.URL + "/probe" + query)
+		if err != nil {
+			t.Fatalf("probe: %v", err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+
+	if status, _ := body(""); status != http.StatusBadRequest {
+		t.Errorf("missing target: expected status 400, got %d", status)
+	}
+
+	_, text := body("?target=" + url.QueryEscape(app.URL))
+	for _, want := range []string{
+		"groupcache_exporter_probe_success 1",
+		`groupcache_gets_total{group="group1",instance="` + app.URL + `"} 7`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("probe output missing %q:\n%s", want, text)
+		}
+	}
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	_, text = body("?target=" + url.QueryEscape(down.URL))
+	if !strings.Contains(text, "groupcache_exporter_probe_success 0") {
+		t.Errorf("failed probe should report success 0:\n%s", text)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/udhos/groupcache_exporter"
+	"github.com/udhos/groupcache_exporter/jsonstats"
+)
+
+// newExporter creates an Exporter with label instance followed by the configured group labels.
+func newExporter(cfg config, listGroups func() []groupcache_exporter.GroupStatistics) *groupcache_exporter.Exporter {
+	return groupcache_exporter.NewExporter(groupcache_exporter.Options{
+		Namespace:   cfg.namespace,
+		Debug:       cfg.debug,
+		SelfMetrics: cfg.selfMetrics,
+		ListGroups:  listGroups,
+		GroupLabels: append([]string{InstanceLabel}, cfg.groupLabels...),
+	})
+}
+
+// poller periodically fetches the JSON stats of every target.
+type poller struct {
+	targets  []string
+	interval time.Duration
+	client   *http.Client
+
+	mutex  sync.Mutex
+	groups map[string][]groupcache_exporter.GroupStatistics // per target
+	up     map[string]bool
+}
+
+func newPoller(cfg config, client *http.Client) *poller {
+	return &poller{
+		targets:  cfg.targets,
+		interval: cfg.interval,
+		client:   client,
+		groups:   map[string][]groupcache_exporter.GroupStatistics{},
+		up:       map[string]bool{},
+	}
+}
+
+func (p *poller) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.pollOnce(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pollOnce fetches all targets concurrently.
+// Groups of a failed target are dropped until the target recovers.
+func (p *poller) pollOnce(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, target := range p.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			doc, err := jsonstats.Fetch(ctx, p.client, target)
+			if err != nil {
+				slog.Error("poll", "target", target, "error", err)
+			}
+			p.mutex.Lock()
+			defer p.mutex.Unlock()
+			p.up[target] = err == nil
+			if err != nil {
+				delete(p.groups, target)
+				return
+			}
+			p.groups[target] = doc.ListGroups(map[string]string{InstanceLabel: target})
+		}()
+	}
+	wg.Wait()
+}
+
+// listGroups returns the groups of all targets from the latest poll.
+func (p *poller) listGroups() []groupcache_exporter.GroupStatistics {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var all []groupcache_exporter.GroupStatistics
+	for _, target := range p.targets {
+		all = append(all, p.groups[target]...)
+	}
+	return all
+}
+
+// upCollector reports whether the latest poll of each target succeeded.
+func (p *poller) upCollector() prometheus.Collector {
+	desc := prometheus.NewDesc("groupcache_exporter_target_up",
+		"Whether the latest poll of the target succeeded", []string{InstanceLabel}, nil)
+	return &upCollector{desc: desc, poller: p}
+}
+
+type upCollector struct {
+	desc   *prometheus.Desc
+	poller *poller
+}
+
+func (c *upCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.desc }
+
+func (c *upCollector) Collect(ch chan<- prometheus.Metric) {
+	c.poller.mutex.Lock()
+	defer c.poller.mutex.Unlock()
+	for target, up := range c.poller.up {
+		var value float64
+		if up {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, value, target)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/udhos/groupcache_exporter"
+	"github.com/udhos/groupcache_exporter/jsonstats"
+)
+
+// probeHandler fetches the target given in the query and exposes its metrics,
+// following the Prometheus multi-target exporter pattern.
+type probeHandler struct {
+	cfg    config
+	client *http.Client
+}
+
+func newProbeHandler(cfg config, client *http.Client) *probeHandler {
+	return &probeHandler{cfg: cfg, client: client}
+}
+
+func (h *probeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		http.Error(w, "missing parameter: target", http.StatusBadRequest)
+		return
+	}
+
+	success := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "groupcache_exporter_probe_success",
+		Help: "Whether the probe of the target succeeded",
+	})
+
+	var groups []groupcache_exporter.GroupStatistics
+
+	doc, err := jsonstats.Fetch(r.Context(), h.client, target)
+	if err != nil {
+		slog.Error("probe", "target", target, "error", err)
+	} else {
+		success.Set(1)
+		groups = doc.ListGroups(map[string]string{InstanceLabel: target})
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(success, newExporter(h.cfg, func() []groupcache_exporter.GroupStatistics { return groups }))
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}).ServeHTTP(w, r)
+}
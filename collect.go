@@ -0,0 +1,192 @@
+package groupcache_exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var errDuplicateGroup = errors.New("duplicate group labels")
+
+// dropDuplicates drops groups sharing the same label values, which would
+// export duplicate series, and reports them as collection failures.
+func (e *Exporter) dropDuplicates(ch chan<- prometheus.Metric, groups []GroupStatistics) []GroupStatistics {
+	keys := make([]string, len(groups))
+	valid := make([]bool, len(groups))
+	count := map[string]int{}
+	for i, g := range groups {
+		var labelValues []string
+		if labelValues, valid[i] = e.safeLabelValues(g); valid[i] {
+			keys[i] = seriesKey(labelValues)
+			count[keys[i]]++
+		}
+	}
+
+	var result []GroupStatistics
+	reported := map[string]bool{}
+	for i, g := range groups {
+		if !valid[i] || count[keys[i]] < 2 {
+			result = append(result, g)
+			continue
+		}
+		if reported[keys[i]] {
+			continue
+		}
+		reported[keys[i]] = true
+		err := fmt.Errorf("%w: %d groups", errDuplicateGroup, count[keys[i]])
+		if missing := e.unexportedGroupLabels(g); len(missing) > 0 {
+			err = fmt.Errorf("%w: add labels %q to Options.GroupLabels", err, missing)
+		}
+		labelValues, _ := e.safeLabelValues(g)
+		e.collectFailure(ch, labelValues, err)
+	}
+	return result
+}
+
+// unexportedGroupLabels returns the names of labels defined by the group
+// that are missing from Options.GroupLabels, recovering from a panic in the group.
+func (e *Exporter) unexportedGroupLabels(group GroupStatistics) (names []string) {
+	defer func() {
+		if r := recover(); r != nil {
+			names = nil
+		}
+	}()
+	gl, ok := group.(GroupLabels)
+	if !ok {
+		return nil
+	}
+	for name := range gl.GroupLabels() {
+		if !slices.Contains(e.options.GroupLabels, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// collectGroups collects groups either sequentially or,
+// when Options.CollectWorkers is greater than 1, by a bounded pool of workers.
+func (e *Exporter) collectGroups(ch chan<- prometheus.Metric, groups []GroupStatistics, sum *totals) {
+	workers := e.options.CollectWorkers
+	if workers <= 1 {
+		for _, group := range groups {
+			e.collectFromGroup(ch, group, sum)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for _, group := range groups {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			e.collectFromGroup(ch, group, sum)
+		})
+	}
+	wg.Wait()
+}
+
+var errCollectTimeout = errors.New("collect timed out")
+
+// collectGroup calls CollectContext for groups implementing
+// GroupStatisticsContext, or falls back to Collect.
+func collectGroup(ctx context.Context, group GroupStatistics) (Stats, error) {
+	if g, ok := group.(GroupStatisticsContext); ok {
+		return g.CollectContext(ctx)
+	}
+	return group.Collect(), nil
+}
+
+// inflightGroups tracks groups whose collection is running in background.
+type inflightGroups struct {
+	mutex sync.Mutex
+	keys  map[string]struct{}
+}
+
+// start marks the group as running, unless it is already running.
+func (f *inflightGroups) start(key string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if _, found := f.keys[key]; found {
+		return false
+	}
+	if f.keys == nil {
+		f.keys = map[string]struct{}{}
+	}
+	f.keys[key] = struct{}{}
+	return true
+}
+
+// done marks the group as no longer running.
+func (f *inflightGroups) done(key string) {
+	f.mutex.Lock()
+	delete(f.keys, key)
+	f.mutex.Unlock()
+}
+
+// groupStats collects the group, giving up after Options.CollectTimeout.
+// The context passed to CollectContext is canceled at the timeout.
+// A group that times out is left running in background, since Collect
+// can not be canceled, and its result is discarded. Until it returns,
+// the group identified by key is reported as timed out without calling
+// Collect again, so a hung group does not leak one goroutine per scrape.
+// A panic in the group is propagated to the caller.
+func (e *Exporter) groupStats(group GroupStatistics, key string) (Stats, error) {
+	timeout := e.options.CollectTimeout
+	if timeout <= 0 {
+		return collectGroup(context.Background(), group)
+	}
+
+	if !e.inflight.start(key) {
+		return Stats{}, errCollectTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type result struct {
+		stats Stats
+		err   error
+	}
+
+	results := make(chan result, 1)
+	panics := make(chan any, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				e.inflight.done(key)
+				panics <- r
+			}
+		}()
+		stats, err := collectGroup(ctx, group)
+		e.inflight.done(key)
+		results <- result{stats, err}
+	}()
+
+	select {
+	case r := <-results:
+		if r.err != nil && ctx.Err() != nil {
+			return Stats{}, errCollectTimeout
+		}
+		return r.stats, r.err
+	case r := <-panics:
+		panic(r)
+	case <-ctx.Done():
+		return Stats{}, errCollectTimeout
+	}
+}
+
+func newCollectTimeouts(namespace string, labels map[string]string, groupLabels []string,
+	builtin *builtinNames) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        builtin.fqName(namespace, "groupcache_exporter", "collect_timeouts_total"),
+		Help:        "Count of groups that timed out during collection",
+		ConstLabels: labels,
+	}, groupLabels)
+}
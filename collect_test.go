@@ -0,0 +1,213 @@
+package groupcache_exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// slowGroup blocks Collect until release is closed.
+type slowGroup struct {
+	fakeGroup
+	release chan struct{}
+}
+
+func (g *slowGroup) Collect() Stats {
+	<-g.release
+	return g.stats
+}
+
+// concurrentGroup tracks the maximum number of concurrent Collect calls.
+type concurrentGroup struct {
+	fakeGroup
+	running *atomic.Int32
+	peak    *atomic.Int32
+}
+
+func (g *concurrentGroup) Collect() Stats {
+	n := g.running.Add(1)
+	defer g.running.Add(-1)
+	for {
+		p := g.peak.Load()
+		if n <= p || g.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return g.stats
+}
+
+// go test -count 1 -run '^TestCollectTimeout$' ./...
+func TestCollectTimeout(t *testing.T) {
+	slow := &slowGroup{fakeGroup: fakeGroup{name: "slow"}, release: make(chan struct{})}
+	defer close(slow.release)
+
+	e := NewExporter(Options{
+		ListGroups:     listGroups(&fakeGroup{name: "fast1"}, slow, &fakeGroup{name: "fast2"}),
+		CollectWorkers: 2,
+		CollectTimeout: 50 * time.Millisecond,
+	})
+
+	if count := testutil.CollectAndCount(e, "groupcache_gets_total"); count != 2 {
+		t.Errorf("expected gets for 2 fast groups, got %d", count)
+	}
+
+	if value := testutil.ToFloat64(e.timeouts.WithLabelValues("slow")); value != 1 {
+		t.Errorf("expected 1 timeout for slow group, got %v", value)
+	}
+}
+
+// go test -count 1 -run '^TestCollectTimeoutHung$' ./...
+func TestCollectTimeoutHung(t *testing.T) {
+	slow := &slowGroup{fakeGroup: fakeGroup{name: "slow"}, release: make(chan struct{})}
+
+	e := NewExporter(Options{
+		ListGroups:     listGroups(slow),
+		CollectTimeout: 10 * time.Millisecond,
+	})
+
+	e.Collect(make(chan prometheus.Metric, 100))
+	before := runtime.NumGoroutine()
+
+	const scrapes = 20
+	for range scrapes {
+		e.Collect(make(chan prometheus.Metric, 100))
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("expected no goroutine leak, got %d goroutines before and %d after %d scrapes",
+			before, after, scrapes)
+	}
+	if value := testutil.ToFloat64(e.timeouts.WithLabelValues("slow")); value != scrapes+1 {
+		t.Errorf("expected %d timeouts, got %v", scrapes+1, value)
+	}
+
+	close(slow.release)
+	for !e.inflight.start("slow") {
+		time.Sleep(time.Millisecond)
+	}
+	e.inflight.done("slow")
+
+	if count := testutil.CollectAndCount(e, "groupcache_gets_total"); count != 1 {
+		t.Errorf("expected gets for released group, got %d series", count)
+	}
+}
+
+// go test -count 1 -run '^TestCollectTimeoutPruned$' ./...
+func TestCollectTimeoutPruned(t *testing.T) {
+	slow := &slowGroup{fakeGroup: fakeGroup{name: "slow"}, release: make(chan struct{})}
+	defer close(slow.release)
+
+	groups := []GroupStatistics{slow}
+	e := NewExporter(Options{
+		ListGroups:     func() []GroupStatistics { return groups },
+		CollectTimeout: 50 * time.Millisecond,
+	})
+
+	e.Collect(make(chan prometheus.Metric, 100))
+
+	if count := testutil.CollectAndCount(e.timeouts); count != 1 {
+		t.Errorf("expected 1 timeout series, got %d", count)
+	}
+
+	groups = []GroupStatistics{&fakeGroup{name: "fast"}}
+	e.Collect(make(chan prometheus.Metric, 100))
+
+	if count := testutil.CollectAndCount(e.timeouts); count != 0 {
+		t.Errorf("expected no timeout series after removal, got %d", count)
+	}
+}
+
+// go test -count 1 -run '^TestCollectWorkers$' ./...
+func TestCollectWorkers(t *testing.T) {
+	var running, peak atomic.Int32
+
+	var groups []GroupStatistics
+	for i := range 10 {
+		groups = append(groups, &concurrentGroup{
+			fakeGroup: fakeGroup{name: fmt.Sprintf("group%d", i)},
+			running:   &running,
+			peak:      &peak,
+		})
+	}
+
+	e := NewExporter(Options{
+		ListGroups:     listGroups(groups...),
+		CollectWorkers: 3,
+	})
+
+	if count := testutil.CollectAndCount(e, "groupcache_gets_total"); count != 10 {
+		t.Errorf("expected gets for 10 groups, got %d", count)
+	}
+
+	if p := peak.Load(); p > 3 || p < 2 {
+		t.Errorf("expected between 2 and 3 concurrent collections, got %d", p)
+	}
+}
+
+// contextGroup implements GroupStatisticsContext.
+type contextGroup struct {
+	fakeGroup
+	err   error
+	block bool
+}
+
+func (g *contextGroup) CollectContext(ctx context.Context) (Stats, error) {
+	if g.block {
+		<-ctx.Done()
+		return Stats{}, ctx.Err()
+	}
+	return g.stats, g.err
+}
+
+// go test -count 1 -run '^TestCollectContext$' ./...
+func TestCollectContext(t *testing.T) {
+	ok := &contextGroup{fakeGroup: fakeGroup{name: "ok"}}
+	ok.stats.Group.CounterGets = 5
+	closed := &contextGroup{fakeGroup: fakeGroup{name: "closed"}, err: errors.New("group closed")}
+	blocked := &contextGroup{fakeGroup: fakeGroup{name: "blocked"}, block: true}
+
+	e := NewExporter(Options{
+		ListGroups:     listGroups(ok, closed, blocked),
+		CollectTimeout: 20 * time.Millisecond,
+		SelfMetrics:    true,
+	})
+
+	ch := make(chan prometheus.Metric, 1000)
+	e.Collect(ch)
+	close(ch)
+
+	var invalid int
+	var gets []float64
+	for m := range ch {
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			invalid++
+			continue
+		}
+		if strings.HasPrefix(m.Desc().String(), `Desc{fqName: "groupcache_gets_total"`) {
+			gets = append(gets, out.GetCounter().GetValue())
+		}
+	}
+	if invalid != 1 {
+		t.Errorf("expected 1 invalid metric for closed group, got %d", invalid)
+	}
+	if len(gets) != 1 || gets[0] != 5 {
+		t.Errorf("expected gets only for ok group, got %v", gets)
+	}
+	if value := testutil.ToFloat64(e.self.collectFailures.WithLabelValues("closed")); value != 1 {
+		t.Errorf("expected 1 failure for closed group, got %v", value)
+	}
+	if value := testutil.ToFloat64(e.timeouts.WithLabelValues("blocked")); value != 1 {
+		t.Errorf("expected 1 timeout for blocked group, got %v", value)
+	}
+}
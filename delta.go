@@ -12,6 +12,9 @@ type CacheDelta struct {
 	LocalLoadsErrs    int64
 	ServerRequests    int64
 	CrosstalkRefusals int64
+
+	// GetFromPeersLatencyLower is the change of the gauge, which may be negative.
+	GetFromPeersLatencyLower float64
 }
 
 // GetCacheDelta calculates deltas for cache stats.
@@ -27,6 +30,8 @@ func GetCacheDelta(prev, curr GroupStats) CacheDelta {
 		LocalLoadsErrs:    curr.CounterLocalLoadsErrs - prev.CounterLocalLoadsErrs,
 		ServerRequests:    curr.CounterServerRequests - prev.CounterServerRequests,
 		CrosstalkRefusals: curr.CounterCrosstalkRefusals - prev.CounterCrosstalkRefusals,
+
+		GetFromPeersLatencyLower: curr.GaugeGetFromPeersLatencyLower - prev.GaugeGetFromPeersLatencyLower,
 	}
 }
 
@@ -36,6 +41,10 @@ type CacheTypeDelta struct {
 	Hits                int64
 	Evictions           int64
 	EvictionsNonExpired int64
+
+	// Items and Bytes are changes of gauges, which may be negative.
+	Items int64
+	Bytes int64
 }
 
 // GetCacheTypeDelta calculates deltas for per-type cache stats.
@@ -45,5 +54,8 @@ func GetCacheTypeDelta(prev, curr CacheTypeStats) CacheTypeDelta {
 		Hits:                curr.CounterCacheHits - prev.CounterCacheHits,
 		Evictions:           curr.CounterCacheEvictions - prev.CounterCacheEvictions,
 		EvictionsNonExpired: curr.CounterCacheEvictionsNonExpired - prev.CounterCacheEvictionsNonExpired,
+
+		Items: curr.GaugeCacheItems - prev.GaugeCacheItems,
+		Bytes: curr.GaugeCacheBytes - prev.GaugeCacheBytes,
 	}
 }
@@ -0,0 +1,139 @@
+package groupcache_exporter
+
+import (
+	"sync"
+	"time"
+)
+
+// StatsDelta holds changes of group stats between two samples.
+type StatsDelta struct {
+	// Elapsed is the time between the previous and the current sample.
+	Elapsed time.Duration
+
+	// Reset reports whether some counter went backwards, for instance
+	// because the group was recreated. Then all counters are assumed
+	// to have restarted from zero, and their deltas are the current values.
+	Reset bool
+
+	Group CacheDelta
+	Main  CacheTypeDelta
+	Hot   CacheTypeDelta
+
+	// Current holds the current sample, useful for gauges.
+	Current Stats
+}
+
+// Rate converts a counter delta into a per-second rate over Elapsed.
+func (d StatsDelta) Rate(delta int64) float64 {
+	if d.Elapsed <= 0 {
+		return 0
+	}
+	return float64(delta) / d.Elapsed.Seconds()
+}
+
+// DeltaTracker computes deltas between successive Stats of each group.
+// It keeps the previous sample per group, hence callers do not need to.
+// DeltaTracker is safe for concurrent use.
+type DeltaTracker struct {
+	now func() time.Time
+
+	mutex sync.Mutex
+	prev  map[string]deltaSample
+}
+
+type deltaSample struct {
+	when  time.Time
+	stats Stats
+}
+
+// NewDeltaTracker creates DeltaTracker.
+func NewDeltaTracker() *DeltaTracker {
+	return &DeltaTracker{
+		now:  time.Now,
+		prev: map[string]deltaSample{},
+	}
+}
+
+// Update records current stats for the group and returns the delta since
+// the previous call for the same group name.
+// ok is false for the first sample of a group, since there is no delta yet.
+func (t *DeltaTracker) Update(groupName string, curr Stats) (delta StatsDelta, ok bool) {
+	now := t.now()
+
+	t.mutex.Lock()
+	prev, found := t.prev[groupName]
+	t.prev[groupName] = deltaSample{when: now, stats: curr}
+	t.mutex.Unlock()
+
+	if !found {
+		return StatsDelta{Current: curr}, false
+	}
+
+	base := prev.stats
+	reset := counterReset(base, curr)
+	if reset {
+		// the group restarted, hence every counter restarted from zero
+		base = gaugesOnly(base)
+	}
+
+	delta = StatsDelta{
+		Elapsed: now.Sub(prev.when),
+		Reset:   reset,
+		Group:   GetCacheDelta(base.Group, curr.Group),
+		Main:    GetCacheTypeDelta(base.Main, curr.Main),
+		Hot:     GetCacheTypeDelta(base.Hot, curr.Hot),
+		Current: curr,
+	}
+
+	return delta, true
+}
+
+// Forget drops the previous sample for the group.
+func (t *DeltaTracker) Forget(groupName string) {
+	t.mutex.Lock()
+	delete(t.prev, groupName)
+	t.mutex.Unlock()
+}
+
+// Groups returns the names of groups with a previous sample.
+func (t *DeltaTracker) Groups() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	names := make([]string, 0, len(t.prev))
+	for name := range t.prev {
+		names = append(names, name)
+	}
+	return names
+}
+
+// counterReset reports whether any counter went backwards.
+// Counters are not checked independently, since a restarted group may
+// have some counters already grown past their previous values.
+func counterReset(prev, curr Stats) bool {
+	g := GetCacheDelta(prev.Group, curr.Group)
+	if g.Gets < 0 || g.Hits < 0 || g.PeerLoads < 0 || g.PeerErrors < 0 || g.Loads < 0 ||
+		g.LoadsDeduped < 0 || g.LocalLoads < 0 || g.LocalLoadsErrs < 0 ||
+		g.ServerRequests < 0 || g.CrosstalkRefusals < 0 {
+		return true
+	}
+	for _, c := range []CacheTypeDelta{
+		GetCacheTypeDelta(prev.Main, curr.Main),
+		GetCacheTypeDelta(prev.Hot, curr.Hot),
+	} {
+		if c.Gets < 0 || c.Hits < 0 || c.Evictions < 0 || c.EvictionsNonExpired < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// gaugesOnly returns stats holding only the gauges from s, with counters zeroed.
+func gaugesOnly(s Stats) Stats {
+	var g Stats
+	g.Group.GaugeGetFromPeersLatencyLower = s.Group.GaugeGetFromPeersLatencyLower
+	g.Main.GaugeCacheItems = s.Main.GaugeCacheItems
+	g.Main.GaugeCacheBytes = s.Main.GaugeCacheBytes
+	g.Hot.GaugeCacheItems = s.Hot.GaugeCacheItems
+	g.Hot.GaugeCacheBytes = s.Hot.GaugeCacheBytes
+	return g
+}
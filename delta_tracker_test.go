@@ -0,0 +1,83 @@
+package groupcache_exporter
+
+import (
+	"testing"
+	"time"
+)
+
+// go test -count 1 -run '^TestDeltaTracker$' ./...
+func TestDeltaTracker(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	tracker := NewDeltaTracker()
+	tracker.now = clock.now
+
+	sample := func(gets, cacheItems int64) Stats {
+		return Stats{
+			Group: GroupStats{CounterGets: gets},
+			Main:  CacheTypeStats{CounterCacheGets: gets, GaugeCacheItems: cacheItems},
+		}
+	}
+
+	if _, ok := tracker.Update("group1", sample(10, 5)); ok {
+		t.Errorf("first sample should not produce delta")
+	}
+
+	clock.advance(10 * time.Second)
+
+	delta, ok := tracker.Update("group1", sample(30, 3))
+	if !ok {
+		t.Fatalf("second sample should produce delta")
+	}
+	if delta.Reset {
+		t.Errorf("unexpected reset")
+	}
+	if delta.Group.Gets != 20 || delta.Main.Gets != 20 {
+		t.Errorf("expected 20 gets, got group=%d main=%d", delta.Group.Gets, delta.Main.Gets)
+	}
+	if rate := delta.Rate(delta.Group.Gets); rate != 2 {
+		t.Errorf("expected rate 2/s, got %v", rate)
+	}
+	if delta.Main.Items != -2 {
+		t.Errorf("expected items delta -2, got %d", delta.Main.Items)
+	}
+
+	clock.advance(10 * time.Second)
+
+	delta, _ = tracker.Update("group1", sample(4, 1)) // group recreated
+	if !delta.Reset {
+		t.Errorf("expected reset")
+	}
+	if delta.Group.Gets != 4 || delta.Main.Gets != 4 {
+		t.Errorf("expected 4 gets after reset, got group=%d main=%d", delta.Group.Gets, delta.Main.Gets)
+	}
+
+	tracker.Forget("group1")
+	if _, ok := tracker.Update("group1", sample(5, 1)); ok {
+		t.Errorf("forgotten group should restart")
+	}
+}
+
+// go test -count 1 -run '^TestDeltaTrackerResetAllCounters$' ./...
+func TestDeltaTrackerResetAllCounters(t *testing.T) {
+	tracker := NewDeltaTracker()
+
+	prev := Stats{Group: GroupStats{CounterGets: 100, CounterHits: 90}}
+	prev.Main.GaugeCacheItems = 10
+	tracker.Update("group1", prev)
+
+	// after the restart, gets already grew past its previous value
+	curr := Stats{Group: GroupStats{CounterGets: 120, CounterHits: 50}}
+	curr.Main.GaugeCacheItems = 4
+	delta, _ := tracker.Update("group1", curr)
+
+	if !delta.Reset {
+		t.Errorf("expected reset")
+	}
+	if delta.Group.Gets != 120 || delta.Group.Hits != 50 {
+		t.Errorf("expected every counter delta from zero, got gets=%d hits=%d",
+			delta.Group.Gets, delta.Group.Hits)
+	}
+	if delta.Main.Items != -6 {
+		t.Errorf("gauge delta should be unaffected by reset, got items=%d", delta.Main.Items)
+	}
+}
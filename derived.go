@@ -0,0 +1,277 @@
+package groupcache_exporter
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DerivedOptions enables derived metrics computed by Exporter from Stats.
+// Ratios are exported as NaN while their denominator is zero.
+type DerivedOptions struct {
+	// HitRatio enables hit_ratio (hits / gets) per group and
+	// cache_hit_ratio (cache hits / cache gets) per group and cache type.
+	HitRatio bool
+
+	// HitRatioWindows enables hit_ratio_window and cache_hit_ratio_window,
+	// the hit ratios computed over each sliding window, for instance 1m and 5m,
+	// under label window.
+	HitRatioWindows []time.Duration
+
+	// DedupRatio enables loads_deduped_ratio (loads deduped / loads).
+	DedupRatio bool
+
+	// PeerErrorRatio enables peer_error_ratio (peer errors / (peer loads + peer errors)).
+	PeerErrorRatio bool
+
+	// LocalLoadErrorRatio enables local_load_error_ratio
+	// (local load errors / (local loads + local load errors)).
+	LocalLoadErrorRatio bool
+}
+
+func (o DerivedOptions) enabled() bool {
+	return o.HitRatio || len(o.HitRatioWindows) > 0 || o.DedupRatio ||
+		o.PeerErrorRatio || o.LocalLoadErrorRatio
+}
+
+// derived holds descriptors and state for derived metrics.
+type derived struct {
+	options DerivedOptions
+	windows *ratioTracker
+
+	hitRatio            *prometheus.Desc
+	cacheHitRatio       *prometheus.Desc
+	hitRatioWindow      *prometheus.Desc
+	cacheHitRatioWindow *prometheus.Desc
+	dedupRatio          *prometheus.Desc
+	peerErrorRatio      *prometheus.Desc
+	localLoadErrorRatio *prometheus.Desc
+}
+
+func newDerived(options DerivedOptions, namespace, subsystem string, labels map[string]string,
+	groupLabels, typeLabels []string, builtin *builtinNames) *derived {
+
+	d := &derived{
+		options: options,
+
+		hitRatio: prometheus.NewDesc(
+			builtin.fqName(namespace, subsystem, "hit_ratio"),
+			"Ratio of cache hits to gets",
+			groupLabels,
+			labels,
+		),
+		cacheHitRatio: prometheus.NewDesc(
+			builtin.fqName(namespace, subsystem, "cache_hit_ratio"),
+			"Ratio of cache hits to cache gets",
+			typeLabels,
+			labels,
+		),
+		hitRatioWindow: prometheus.NewDesc(
+			builtin.fqName(namespace, subsystem, "hit_ratio_window"),
+			"Ratio of cache hits to gets within the sliding window",
+			append(slices.Clone(groupLabels), "window"),
+			labels,
+		),
+		cacheHitRatioWindow: prometheus.NewDesc(
+			builtin.fqName(namespace, subsystem, "cache_hit_ratio_window"),
+			"Ratio of cache hits to cache gets within the sliding window",
+			append(slices.Clone(typeLabels), "window"),
+			labels,
+		),
+		dedupRatio: prometheus.NewDesc(
+			builtin.fqName(namespace, subsystem, "loads_deduped_ratio"),
+			"Ratio of loads after singleflight to loads",
+			groupLabels,
+			labels,
+		),
+		peerErrorRatio: prometheus.NewDesc(
+			builtin.fqName(namespace, subsystem, "peer_error_ratio"),
+			"Ratio of peer errors to peer requests",
+			groupLabels,
+			labels,
+		),
+		localLoadErrorRatio: prometheus.NewDesc(
+			builtin.fqName(namespace, subsystem, "local_load_error_ratio"),
+			"Ratio of failed local loads to local loads",
+			groupLabels,
+			labels,
+		),
+	}
+
+	if len(options.HitRatioWindows) > 0 {
+		d.windows = newRatioTracker(options.HitRatioWindows)
+	}
+
+	return d
+}
+
+func (d *derived) describe(ch chan<- *prometheus.Desc, supported Metrics) {
+	hits := MetricGets | MetricHits
+	cacheHits := MetricCacheGets | MetricCacheHits
+	if d.options.HitRatio && supported.Has(hits) {
+		ch <- d.hitRatio
+	}
+	if d.options.HitRatio && supported.Has(cacheHits) {
+		ch <- d.cacheHitRatio
+	}
+	if d.windows != nil && supported.Has(hits) {
+		ch <- d.hitRatioWindow
+	}
+	if d.windows != nil && supported.Has(cacheHits) {
+		ch <- d.cacheHitRatioWindow
+	}
+	if d.options.DedupRatio && supported.Has(MetricLoads|MetricLoadsDeduped) {
+		ch <- d.dedupRatio
+	}
+	if d.options.PeerErrorRatio && supported.Has(MetricPeerLoads|MetricPeerErrors) {
+		ch <- d.peerErrorRatio
+	}
+	if d.options.LocalLoadErrorRatio && supported.Has(MetricLocalLoads|MetricLocalLoadsErrs) {
+		ch <- d.localLoadErrorRatio
+	}
+}
+
+func (d *derived) collect(ch chan<- prometheus.Metric, debug bool, g GroupStats, caches []cacheType,
+	labelValues []string, supported Metrics) {
+
+	if supported.Has(MetricGets | MetricHits) {
+		if d.options.HitRatio {
+			ch <- metric(debug, "hit_ratio", d.hitRatio, prometheus.GaugeValue,
+				ratio(g.CounterHits, g.CounterGets), labelValues...)
+		}
+		d.collectWindows(ch, debug, "hit_ratio_window", d.hitRatioWindow, g.CounterHits, g.CounterGets, labelValues)
+	}
+
+	if supported.Has(MetricCacheGets | MetricCacheHits) {
+		for _, c := range caches {
+			typeLabelValues := append(slices.Clone(labelValues), c.name)
+			if d.options.HitRatio {
+				ch <- metric(debug, "cache_hit_ratio", d.cacheHitRatio, prometheus.GaugeValue,
+					ratio(c.stats.CounterCacheHits, c.stats.CounterCacheGets), typeLabelValues...)
+			}
+			d.collectWindows(ch, debug, "cache_hit_ratio_window", d.cacheHitRatioWindow, c.stats.CounterCacheHits,
+				c.stats.CounterCacheGets, typeLabelValues)
+		}
+	}
+
+	if d.options.DedupRatio && supported.Has(MetricLoads|MetricLoadsDeduped) {
+		ch <- metric(debug, "loads_deduped_ratio", d.dedupRatio, prometheus.GaugeValue,
+			ratio(g.CounterLoadsDeduped, g.CounterLoads), labelValues...)
+	}
+
+	if d.options.PeerErrorRatio && supported.Has(MetricPeerLoads|MetricPeerErrors) {
+		ch <- metric(debug, "peer_error_ratio", d.peerErrorRatio, prometheus.GaugeValue,
+			ratio(g.CounterPeerErrors, g.CounterPeerLoads+g.CounterPeerErrors), labelValues...)
+	}
+
+	if d.options.LocalLoadErrorRatio && supported.Has(MetricLocalLoads|MetricLocalLoadsErrs) {
+		ch <- metric(debug, "local_load_error_ratio", d.localLoadErrorRatio, prometheus.GaugeValue,
+			ratio(g.CounterLocalLoadsErrs, g.CounterLocalLoads+g.CounterLocalLoadsErrs), labelValues...)
+	}
+}
+
+func (d *derived) collectWindows(ch chan<- prometheus.Metric, debug bool, name string, desc *prometheus.Desc,
+	numerator, denominator int64, labelValues []string) {
+	if d.windows == nil {
+		return
+	}
+	values := d.windows.observe(seriesKey(labelValues), numerator, denominator)
+	for i, w := range d.windows.windows {
+		ch <- metric(debug, name, desc, prometheus.GaugeValue, values[i],
+			append(slices.Clone(labelValues), windowLabel(w))...)
+	}
+}
+
+// ratio returns numerator / denominator, or NaN when denominator is not positive.
+func ratio(numerator, denominator int64) float64 {
+	if denominator <= 0 {
+		return math.NaN()
+	}
+	return float64(numerator) / float64(denominator)
+}
+
+// ratioTracker computes the ratio of two counters over sliding windows.
+type ratioTracker struct {
+	windows   []time.Duration
+	maxWindow time.Duration
+	now       func() time.Time
+
+	mutex  sync.Mutex
+	series map[string][]ratioSample
+}
+
+type ratioSample struct {
+	when        time.Time
+	numerator   int64
+	denominator int64
+}
+
+func newRatioTracker(windows []time.Duration) *ratioTracker {
+	return &ratioTracker{
+		windows:   windows,
+		maxWindow: slices.Max(windows),
+		now:       time.Now,
+		series:    map[string][]ratioSample{},
+	}
+}
+
+// expire forgets series not observed for longer than the largest window.
+// It is called once per scrape, rather than by observe, since it visits every series.
+func (t *ratioTracker) expire() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := t.now()
+	for k, samples := range t.series {
+		if now.Sub(samples[len(samples)-1].when) > t.maxWindow {
+			delete(t.series, k)
+		}
+	}
+}
+
+// observe records current counters for the series identified by key and returns
+// the ratio of counter increases for each window, in the same order as windows.
+// A counter lower than the previous sample means the series was reset,
+// hence older samples are replaced by a zero baseline.
+func (t *ratioTracker) observe(key string, numerator, denominator int64) []float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.now()
+
+	samples := t.series[key]
+	if n := len(samples); n > 0 {
+		last := samples[n-1]
+		if numerator < last.numerator || denominator < last.denominator {
+			// reset: counters restarted from zero after the last sample
+			samples = []ratioSample{{when: last.when}}
+		}
+	}
+	samples = append(samples, ratioSample{when: now, numerator: numerator, denominator: denominator})
+
+	// keep one sample older than the largest window as baseline
+	cut := 0
+	for cut+1 < len(samples) && now.Sub(samples[cut+1].when) >= t.maxWindow {
+		cut++
+	}
+	samples = samples[cut:]
+	t.series[key] = samples
+
+	result := make([]float64, len(t.windows))
+	for i, w := range t.windows {
+		// baseline is the latest sample at least one window old,
+		// or the oldest sample if the series is younger than the window.
+		base := samples[0]
+		for _, s := range samples {
+			if now.Sub(s.when) < w {
+				break
+			}
+			base = s
+		}
+		result[i] = ratio(numerator-base.numerator, denominator-base.denominator)
+	}
+
+	return result
+}
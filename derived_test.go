@@ -0,0 +1,113 @@
+package groupcache_exporter
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// go test -count 1 -run '^TestDerivedRatios$' ./...
+func TestDerivedRatios(t *testing.T) {
+	g := &fakeGroup{
+		name: "group1",
+		stats: Stats{
+			Group: GroupStats{
+				CounterGets:           10,
+				CounterHits:           8,
+				CounterLoads:          2,
+				CounterLoadsDeduped:   1,
+				CounterLocalLoads:     3,
+				CounterLocalLoadsErrs: 1,
+			},
+			Main: CacheTypeStats{CounterCacheGets: 4, CounterCacheHits: 3},
+		},
+	}
+
+	e := NewExporter(Options{
+		ListGroups: listGroups(g),
+		Derived: DerivedOptions{
+			HitRatio:            true,
+			DedupRatio:          true,
+			PeerErrorRatio:      true,
+			LocalLoadErrorRatio: true,
+		},
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(e)
+
+	expected := `
+# HELP groupcache_cache_hit_ratio Ratio of cache hits to cache gets
+# TYPE groupcache_cache_hit_ratio gauge
+groupcache_cache_hit_ratio{group="group1",type="hot"} NaN
+groupcache_cache_hit_ratio{group="group1",type="main"} 0.75
+# HELP groupcache_hit_ratio Ratio of cache hits to gets
+# TYPE groupcache_hit_ratio gauge
+groupcache_hit_ratio{group="group1"} 0.8
+# HELP groupcache_loads_deduped_ratio Ratio of loads after singleflight to loads
+# TYPE groupcache_loads_deduped_ratio gauge
+groupcache_loads_deduped_ratio{group="group1"} 0.5
+# HELP groupcache_local_load_error_ratio Ratio of failed local loads to local loads
+# TYPE groupcache_local_load_error_ratio gauge
+groupcache_local_load_error_ratio{group="group1"} 0.25
+# HELP groupcache_peer_error_ratio Ratio of peer errors to peer requests
+# TYPE groupcache_peer_error_ratio gauge
+groupcache_peer_error_ratio{group="group1"} NaN
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"groupcache_hit_ratio", "groupcache_cache_hit_ratio", "groupcache_loads_deduped_ratio",
+		"groupcache_peer_error_ratio", "groupcache_local_load_error_ratio"); err != nil {
+		t.Errorf("derived ratios: %v", err)
+	}
+}
+
+// go test -count 1 -run '^TestRatioTracker$' ./...
+func TestRatioTracker(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	tracker := newRatioTracker([]time.Duration{time.Minute, 5 * time.Minute})
+	tracker.now = clock.now
+
+	steps := []struct {
+		advance    time.Duration
+		hits, gets int64
+		expected1m float64
+		expected5m float64
+	}{
+		{0, 0, 0, math.NaN(), math.NaN()},
+		{time.Minute, 10, 10, 1, 1},   // all hits
+		{time.Minute, 10, 20, 0, .5},  // all misses in last minute
+		{time.Minute, 15, 30, .5, .5}, // half hits in last minute
+		{time.Minute, 5, 10, .5, .5},  // counter reset: baseline is the reset sample
+		{time.Minute, 5, 10, math.NaN(), .5},
+	}
+
+	for i, s := range steps {
+		clock.advance(s.advance)
+		got := tracker.observe("group1", s.hits, s.gets)
+		if !sameFloat(got[0], s.expected1m) || !sameFloat(got[1], s.expected5m) {
+			t.Errorf("step %d: expected 1m=%v 5m=%v got %v", i, s.expected1m, s.expected5m, got)
+		}
+	}
+}
+
+func sameFloat(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	return a == b
+}
+
+// go test -count 1 -run '^TestHitRatioWindowsInvalid$' ./...
+func TestHitRatioWindowsInvalid(t *testing.T) {
+	e := NewExporter(Options{
+		ListGroups: listGroups(),
+		Derived:    DerivedOptions{HitRatioWindows: []time.Duration{5 * time.Minute, 300 * time.Second}},
+	})
+	if err := prometheus.NewRegistry().Register(e); err == nil {
+		t.Errorf("expected registration error for duplicate window")
+	}
+}
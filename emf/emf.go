@@ -0,0 +1,254 @@
+// Package emf writes groupcache metrics as CloudWatch Embedded Metric Format (EMF) documents.
+// Counters are written as deltas since the previous call to Emit, gauges as current values.
+//
+//	emitter := emf.New(emf.Options{
+//		ListGroups: func() []groupcache_exporter.GroupStatistics { return modernprogram.ListGroups(workspace) },
+//		Labels:     map[string]string{"app": appName},
+//	})
+//	// at the end of each invocation:
+//	emitter.Emit()
+package emf
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/udhos/groupcache_exporter"
+)
+
+// CloudWatch units.
+const (
+	UnitCount        = "Count"
+	UnitBytes        = "Bytes"
+	UnitMilliseconds = "Milliseconds"
+)
+
+// Options define parameters for Emitter.
+type Options struct {
+	// Writer receives one JSON document per line. If undefined, defaults to os.Stdout.
+	Writer io.Writer
+
+	// Namespace is the CloudWatch namespace. If undefined, defaults to "groupcache".
+	Namespace string
+
+	// ListGroups is usually the same function given to groupcache_exporter.Options.
+	ListGroups func() []groupcache_exporter.GroupStatistics
+
+	// Labels are written as properties on every document, like groupcache_exporter.Options.Labels.
+	// Labels from groups implementing groupcache_exporter.GroupLabels are written as well.
+	Labels map[string]string
+
+	// Dimensions selects which of group, type, group labels and Labels keys are used as
+	// CloudWatch dimensions. Properties not selected are still written,
+	// so they are searchable in CloudWatch Logs. If undefined, all are used.
+	Dimensions []string
+
+	// Supported restricts the metrics written. If undefined, defaults to AllMetrics.
+	Supported groupcache_exporter.Metrics
+}
+
+// Emitter writes EMF documents with groupcache metrics.
+type Emitter struct {
+	options Options
+	tracker *groupcache_exporter.DeltaTracker
+	now     func() time.Time
+
+	mutex sync.Mutex // serializes writes
+}
+
+// New creates Emitter.
+func New(options Options) *Emitter {
+	if options.Writer == nil {
+		options.Writer = os.Stdout
+	}
+	if options.Namespace == "" {
+		options.Namespace = "groupcache"
+	}
+	if options.Supported == 0 {
+		options.Supported = groupcache_exporter.AllMetrics
+	}
+	return &Emitter{
+		options: options,
+		tracker: groupcache_exporter.NewDeltaTracker(),
+		now:     time.Now,
+	}
+}
+
+type metricDefinition struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type metricDirective struct {
+	Namespace  string             `json:"Namespace"`
+	Dimensions [][]string         `json:"Dimensions"`
+	Metrics    []metricDefinition `json:"Metrics"`
+}
+
+type metadata struct {
+	Timestamp         int64             `json:"Timestamp"`
+	CloudWatchMetrics []metricDirective `json:"CloudWatchMetrics"`
+}
+
+// document holds properties and metric values for a single EMF document.
+type document struct {
+	properties map[string]string
+	metrics    []metricDefinition
+	values     map[string]any
+}
+
+func (d *document) add(name, unit string, value any) {
+	d.metrics = append(d.metrics, metricDefinition{Name: name, Unit: unit})
+	d.values[name] = value
+}
+
+// Emit writes one document with group metrics and one document per cache type
+// for every group. Counters are only written for groups seen by the previous call.
+// Write errors do not stop Emit, and are returned combined.
+func (e *Emitter) Emit() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	seen := map[string]struct{}{}
+
+	var errs []error
+
+	for _, group := range e.options.ListGroups() {
+		name := group.Name()
+		groupLabels := groupcache_exporter.LabelsOf(group)
+		key := groupcache_exporter.GroupKey(group)
+		seen[key] = struct{}{}
+		supported := e.options.Supported & groupcache_exporter.SupportedBy(group)
+
+		delta, withCounters := e.tracker.Update(key, group.Collect())
+
+		for _, doc := range e.documents(name, groupLabels, supported, delta, withCounters) {
+			if err := e.write(doc); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, key := range e.tracker.Groups() {
+		if _, found := seen[key]; !found {
+			e.tracker.Forget(key)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (e *Emitter) newDocument(groupName string, groupLabels map[string]string, cacheType string) *document {
+	properties := maps.Clone(e.options.Labels)
+	if properties == nil {
+		properties = map[string]string{}
+	}
+	maps.Copy(properties, groupLabels)
+	properties["group"] = groupName
+	if cacheType != "" {
+		properties["type"] = cacheType
+	}
+	return &document{properties: properties, values: map[string]any{}}
+}
+
+func (e *Emitter) documents(groupName string, groupLabels map[string]string, supported groupcache_exporter.Metrics,
+	d groupcache_exporter.StatsDelta, withCounters bool) []*document {
+
+	groupDoc := e.newDocument(groupName, groupLabels, "")
+
+	counter := func(doc *document, m groupcache_exporter.Metrics, name string, value int64) {
+		if withCounters && supported.Has(m) {
+			doc.add(name, UnitCount, value)
+		}
+	}
+
+	g := d.Group
+	counter(groupDoc, groupcache_exporter.MetricGets, "gets", g.Gets)
+	counter(groupDoc, groupcache_exporter.MetricHits, "hits", g.Hits)
+	counter(groupDoc, groupcache_exporter.MetricPeerLoads, "peer_loads", g.PeerLoads)
+	counter(groupDoc, groupcache_exporter.MetricPeerErrors, "peer_errors", g.PeerErrors)
+	counter(groupDoc, groupcache_exporter.MetricLoads, "loads", g.Loads)
+	counter(groupDoc, groupcache_exporter.MetricLoadsDeduped, "loads_deduped", g.LoadsDeduped)
+	counter(groupDoc, groupcache_exporter.MetricLocalLoads, "local_loads", g.LocalLoads)
+	counter(groupDoc, groupcache_exporter.MetricLocalLoadsErrs, "local_load_errs", g.LocalLoadsErrs)
+	counter(groupDoc, groupcache_exporter.MetricServerRequests, "server_requests", g.ServerRequests)
+	counter(groupDoc, groupcache_exporter.MetricCrosstalkRefusals, "crosstalk_refusals", g.CrosstalkRefusals)
+	if supported.Has(groupcache_exporter.MetricGetFromPeersLatencyLower) {
+		groupDoc.add("get_from_peers_latency_slowest", UnitMilliseconds, d.Current.Group.GaugeGetFromPeersLatencyLower)
+	}
+
+	docs := []*document{groupDoc}
+
+	for _, t := range []struct {
+		cacheType string
+		delta     groupcache_exporter.CacheTypeDelta
+		curr      groupcache_exporter.CacheTypeStats
+	}{
+		{"main", d.Main, d.Current.Main},
+		{"hot", d.Hot, d.Current.Hot},
+	} {
+		doc := e.newDocument(groupName, groupLabels, t.cacheType)
+		if supported.Has(groupcache_exporter.MetricCacheItems) {
+			doc.add("cache_items", UnitCount, t.curr.GaugeCacheItems)
+		}
+		if supported.Has(groupcache_exporter.MetricCacheBytes) {
+			doc.add("cache_bytes", UnitBytes, t.curr.GaugeCacheBytes)
+		}
+		counter(doc, groupcache_exporter.MetricCacheGets, "cache_gets", t.delta.Gets)
+		counter(doc, groupcache_exporter.MetricCacheHits, "cache_hits", t.delta.Hits)
+		counter(doc, groupcache_exporter.MetricCacheEvictions, "cache_evictions", t.delta.Evictions)
+		counter(doc, groupcache_exporter.MetricCacheEvictionsNonExpired, "cache_evictions_nonexpired", t.delta.EvictionsNonExpired)
+		docs = append(docs, doc)
+	}
+
+	return docs
+}
+
+// dimensions returns the dimension set for the document properties.
+func (e *Emitter) dimensions(properties map[string]string) []string {
+	dims := []string{}
+	for _, k := range slices.Sorted(maps.Keys(properties)) {
+		if len(e.options.Dimensions) == 0 || slices.Contains(e.options.Dimensions, k) {
+			dims = append(dims, k)
+		}
+	}
+	return dims
+}
+
+func (e *Emitter) write(doc *document) error {
+	if len(doc.metrics) == 0 {
+		return nil
+	}
+
+	root := make(map[string]any, len(doc.properties)+len(doc.values)+1)
+	for k, v := range doc.properties {
+		root[k] = v
+	}
+	for k, v := range doc.values {
+		root[k] = v
+	}
+	root["_aws"] = metadata{
+		Timestamp: e.now().UnixMilli(),
+		CloudWatchMetrics: []metricDirective{
+			{
+				Namespace:  e.options.Namespace,
+				Dimensions: [][]string{e.dimensions(doc.properties)},
+				Metrics:    doc.metrics,
+			},
+		},
+	}
+
+	data, err := json.Marshal(root)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = e.options.Writer.Write(data)
+	return err
+}
@@ -0,0 +1,176 @@
+package emf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/udhos/groupcache_exporter"
+)
+
+type fakeGroup struct {
+	stats groupcache_exporter.Stats
+}
+
+func (g *fakeGroup) Collect() groupcache_exporter.Stats { return g.stats }
+
+func (g *fakeGroup) Name() string { return "group1" }
+
+func decode(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var docs []map[string]any
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var doc map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			t.Fatalf("invalid json: %v: %s", err, scanner.Text())
+		}
+		docs = append(docs, doc)
+	}
+	buf.Reset()
+	return docs
+}
+
+// go test -count 1 -run '^TestEmit$' ./...
+func TestEmit(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	g := &fakeGroup{}
+
+	e := New(Options{
+		Writer:     &buf,
+		ListGroups: func() []groupcache_exporter.GroupStatistics { return []groupcache_exporter.GroupStatistics{g} },
+		Labels:     map[string]string{"app": "test", "pod": "pod1"},
+		Dimensions: []string{"app", "group", "type"},
+	})
+
+	g.stats.Group.CounterGets = 10
+	g.stats.Main.GaugeCacheBytes = 100
+
+	if err := e.Emit(); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+	if docs := decode(t, &buf); len(docs) != 3 {
+		t.Fatalf("baseline: expected group doc with latency gauge plus 2 cache docs, got %d", len(docs))
+	}
+
+	g.stats.Group.CounterGets = 25
+
+	if err := e.Emit(); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+	docs := decode(t, &buf)
+	if len(docs) != 3 {
+		t.Fatalf("expected 3 docs, got %d", len(docs))
+	}
+
+	groupDoc := docs[0]
+	if groupDoc["gets"] != 15.0 || groupDoc["group"] != "group1" || groupDoc["pod"] != "pod1" {
+		t.Errorf("unexpected group doc: %v", groupDoc)
+	}
+
+	directive := groupDoc["_aws"].(map[string]any)["CloudWatchMetrics"].([]any)[0].(map[string]any)
+	dims := directive["Dimensions"].([]any)[0].([]any)
+	if len(dims) != 2 || dims[0] != "app" || dims[1] != "group" {
+		t.Errorf("unexpected group dimensions: %v", dims)
+	}
+
+	mainDoc := docs[1]
+	if mainDoc["type"] != "main" || mainDoc["cache_bytes"] != 100.0 {
+		t.Errorf("unexpected main doc: %v", mainDoc)
+	}
+	mainDirective := mainDoc["_aws"].(map[string]any)["CloudWatchMetrics"].([]any)[0].(map[string]any)
+	for _, m := range mainDirective["Metrics"].([]any) {
+		def := m.(map[string]any)
+		if def["Name"] == "cache_bytes" && def["Unit"] != UnitBytes {
+			t.Errorf("cache_bytes unit: expected Bytes, got %v", def["Unit"])
+		}
+	}
+}
+
+// workspaceGroup reports label workspace, like the modernprogram Workspaces adapter.
+type workspaceGroup struct {
+	fakeGroup
+	workspace string
+}
+
+func (g *workspaceGroup) GroupLabels() map[string]string {
+	return map[string]string{"workspace": g.workspace}
+}
+
+// go test -count 1 -run '^TestEmitGroupLabels$' ./...
+func TestEmitGroupLabels(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	ws1 := &workspaceGroup{workspace: "ws1"}
+	ws2 := &workspaceGroup{workspace: "ws2"}
+
+	e := New(Options{
+		Writer: &buf,
+		ListGroups: func() []groupcache_exporter.GroupStatistics {
+			return []groupcache_exporter.GroupStatistics{ws1, ws2}
+		},
+		Supported: groupcache_exporter.MetricGets,
+	})
+
+	ws1.stats.Group.CounterGets = 10
+	ws2.stats.Group.CounterGets = 100
+	e.Emit() // baseline
+	decode(t, &buf)
+
+	ws1.stats.Group.CounterGets = 15
+	ws2.stats.Group.CounterGets = 130
+	if err := e.Emit(); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+
+	gets := map[string]float64{}
+	for _, doc := range decode(t, &buf) {
+		gets[doc["workspace"].(string)] = doc["gets"].(float64)
+	}
+	if gets["ws1"] != 5 || gets["ws2"] != 30 {
+		t.Errorf("expected gets per workspace ws1=5 ws2=30, got %v", gets)
+	}
+}
+
+// failWriter fails writes while fail is set.
+type failWriter struct{ fail bool }
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, errors.New("write failed")
+	}
+	return len(p), nil
+}
+
+// go test -count 1 -run '^TestEmitWriteError$' ./...
+func TestEmitWriteError(t *testing.T) {
+
+	w := &failWriter{}
+	ws1 := &workspaceGroup{workspace: "ws1"}
+	ws2 := &workspaceGroup{workspace: "ws2"}
+	groups := []groupcache_exporter.GroupStatistics{ws1, ws2}
+
+	e := New(Options{
+		Writer:     w,
+		ListGroups: func() []groupcache_exporter.GroupStatistics { return groups },
+	})
+
+	if err := e.Emit(); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+
+	w.fail = true
+	groups = []groupcache_exporter.GroupStatistics{ws1}
+
+	if err := e.Emit(); err == nil {
+		t.Errorf("expected write error")
+	}
+	if n := len(e.tracker.Groups()); n != 1 {
+		t.Errorf("removed group should be forgotten despite write errors, got %d groups", n)
+	}
+}
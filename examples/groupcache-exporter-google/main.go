@@ -25,6 +25,8 @@ func main() {
 
 	appName := filepath.Base(os.Args[0])
 
+	google.RegisterGroupHook() // track groups for google.ListAllGroups
+
 	cache := startGroupcache()
 
 	//
@@ -44,7 +46,8 @@ func main() {
 			Namespace:  namespace,
 			Labels:     labels,
 			Debug:      debug,
-			ListGroups: func() []groupcache_exporter.GroupStatistics { return google.ListGroups([]*groupcache.Group{cache}) },
+			ListGroups: google.ListAllGroups,
+			Supported:  google.Supported,
 		}
 		collector := groupcache_exporter.NewExporter(options)
 
@@ -45,6 +45,10 @@ func main() {
 			Labels:     labels,
 			Debug:      debug,
 			ListGroups: func() []groupcache_exporter.GroupStatistics { return mailgun.ListGroups() },
+			Supported:  mailgun.Supported,
+
+			LatencyWindows: []time.Duration{time.Minute, 5 * time.Minute},
+			Lifecycle:      true,
 		}
 		collector := groupcache_exporter.NewExporter(options)
 
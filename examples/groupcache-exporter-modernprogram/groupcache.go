@@ -8,9 +8,10 @@ import (
 	"time"
 
 	"github.com/modernprogram/groupcache/v2"
+	"github.com/udhos/groupcache_exporter/server"
 )
 
-func startGroupcache(workspace *groupcache.Workspace) []*groupcache.Group {
+func startGroupcache(workspace *groupcache.Workspace, serverMetrics *server.Metrics) []*groupcache.Group {
 
 	ttl := time.Minute
 
@@ -32,7 +33,7 @@ func startGroupcache(workspace *groupcache.Workspace) []*groupcache.Group {
 	// start groupcache server
 	//
 
-	serverGroupCache := &http.Server{Addr: groupcachePort, Handler: pool}
+	serverGroupCache := &http.Server{Addr: groupcachePort, Handler: serverMetrics.Wrap(pool)}
 
 	go func() {
 		log.Printf("groupcache server: listening on %s", groupcachePort)
@@ -15,6 +15,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/udhos/groupcache_exporter"
 	"github.com/udhos/groupcache_exporter/groupcache/modernprogram"
+	"github.com/udhos/groupcache_exporter/server"
 )
 
 func main() {
@@ -27,7 +28,13 @@ func main() {
 
 	workspace := groupcache.NewWorkspace()
 
-	caches := startGroupcache(workspace)
+	labels := map[string]string{
+		"app": appName,
+	}
+
+	serverMetrics := server.New(server.Options{Labels: labels})
+
+	caches := startGroupcache(workspace, serverMetrics)
 
 	//
 	// expose prometheus metrics
@@ -39,15 +46,16 @@ func main() {
 		log.Printf("starting metrics server at: %s %s",
 			metricsPort, metricsRoute)
 
-		labels := map[string]string{
-			"app": appName,
-		}
 		namespace := ""
 		options := groupcache_exporter.Options{
 			Namespace:  namespace,
 			Labels:     labels,
 			Debug:      debug,
 			ListGroups: func() []groupcache_exporter.GroupStatistics { return modernprogram.ListGroups(workspace) },
+			Supported:  modernprogram.Supported,
+
+			LatencyWindows: []time.Duration{time.Minute, 5 * time.Minute},
+			Lifecycle:      true,
 		}
 		collector := groupcache_exporter.NewExporter(options)
 
@@ -2,15 +2,40 @@
 package groupcache_exporter
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Exporter implements interface prometheus.Collector to extract metrics from groupcache.
+//
+// A group that fails to be collected, either because it panics, returns an
+// error from CollectContext or has label values that are not valid UTF-8,
+// is reported as an invalid metric and logged, while other groups are still
+// exported. Serve metrics with promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}
+// in order to keep the partial results instead of failing the whole scrape.
 type Exporter struct {
 	options Options
 
+	latency   *latencyTracker
+	derived   *derived
+	self      *selfMetrics
+	pruner    *seriesPruner
+	timeouts  *prometheus.CounterVec
+	inflight  inflightGroups
+	limiter   *groupLimiter
+	pattern   *groupNamePattern
+	extra     *extraMetrics
+	lifecycle *lifecycle
+	err       error // invalid options
+
 	groupGets                     *prometheus.Desc
 	groupCacheHits                *prometheus.Desc
 	groupGetFromPeersLatencyLower *prometheus.Desc
@@ -23,6 +48,10 @@ type Exporter struct {
 	groupServerRequests           *prometheus.Desc
 	groupCrosstalkRefusals        *prometheus.Desc
 
+	groupGetFromPeersLatencyWindow *prometheus.Desc
+
+	collectError *prometheus.Desc
+
 	cacheBytes               *prometheus.Desc
 	cacheItems               *prometheus.Desc
 	cacheGets                *prometheus.Desc
@@ -43,16 +72,137 @@ type GroupStatistics interface {
 	Name() string
 }
 
+// GroupStatisticsContext is an optional interface for GroupStatistics.
+// Exporter prefers CollectContext over Collect for groups implementing it.
+// The context is canceled when Options.CollectTimeout expires.
+// An error, for instance due to a closed group, is logged and reported
+// as an invalid metric, and the group is left out from the scrape.
+type GroupStatisticsContext interface {
+	// CollectContext requests metrics collection from implementation.
+	CollectContext(ctx context.Context) (Stats, error)
+}
+
+// GroupLabels is an optional interface for GroupStatistics.
+// An implementation may implement it in order to attach values
+// for the extra variable labels declared in Options.GroupLabels.
+// Labels missing from the returned map are exported as empty.
+type GroupLabels interface {
+	// GroupLabels returns label values for the group, keyed by label name.
+	GroupLabels() map[string]string
+}
+
 // Options define parameters for Exporter.
 type Options struct {
 	Namespace  string
 	Labels     map[string]string
 	Debug      bool
 	ListGroups func() []GroupStatistics
+
+	// Supported restricts the metric families exposed by Exporter,
+	// both in Describe and Collect. Adapters usually provide a
+	// suitable value. If undefined, defaults to AllMetrics.
+	Supported Metrics
+
+	// Disabled drops metric families from Supported, for instance
+	// MetricCrosstalkRefusals. See ParseMetrics for names.
+	Disabled Metrics
+
+	// DisableHotCache drops per-type series with type="hot".
+	DisableHotCache bool
+
+	// IncludeGroups, if defined, restricts exported groups to those
+	// matching any rule.
+	IncludeGroups []GroupRule
+
+	// ExcludeGroups drops groups matching any rule,
+	// even if matched by IncludeGroups.
+	ExcludeGroups []GroupRule
+
+	// LatencyWindows enables metric get_from_peers_latency_window_max_milliseconds,
+	// which reports the slowest peer latency seen within each sliding window,
+	// for instance 1m and 5m, under label window.
+	// Windows are only meaningful for implementations that track peer latency.
+	// If undefined, the windowed metric is not exported.
+	LatencyWindows []time.Duration
+
+	// GroupLabels declares extra variable labels added to every metric,
+	// after label group. Values are provided by groups implementing
+	// interface GroupLabels. For instance, the modernprogram adapter
+	// provides label workspace. Groups sharing the same label values,
+	// like same-named groups in distinct workspaces, fail collection.
+	GroupLabels []string
+
+	// GroupNamePattern is a regular expression with named capture groups
+	// applied to the group name, like ^(?P<tenant>[^:]+):(?P<dataset>[^:]+)$.
+	// Each named capture group declares an extra variable label added to
+	// every metric, after GroupLabels. Label names must not clash with
+	// other labels. An invalid pattern fails the Exporter registration.
+	GroupNamePattern string
+
+	// GroupNameFallback is the value for labels from GroupNamePattern
+	// when the group name does not match the pattern.
+	GroupNameFallback string
+
+	// Derived enables derived metrics, like hit ratios, computed from Stats.
+	Derived DerivedOptions
+
+	// SelfMetrics enables metrics about the exporter own collection cost,
+	// under subsystem groupcache_exporter: scrape duration, ListGroups duration,
+	// number of groups, per-group collection duration and collection failures.
+	// Per-group series are deleted once the group is no longer collected.
+	SelfMetrics bool
+
+	// SelfMetricsBuckets defines buckets for group_collect_duration_seconds.
+	// If undefined, buckets range from 10us to 2.6s.
+	SelfMetricsBuckets []float64
+
+	// Totals enables an extra group named TotalGroups holding stats summed
+	// across all exported groups, including groups folded into OtherGroups.
+	// The slowest peer latency is the maximum across groups.
+	// Its labels from GroupLabels are exported as empty.
+	// A listed group named TotalGroups fails collection.
+	Totals bool
+
+	// Lifecycle enables metrics group_up and group_first_seen_timestamp_seconds
+	// for every listed group not excluded by filters, including groups folded
+	// into OtherGroups, which remain up. A group no longer listed by ListGroups is
+	// reported with group_up 0 during RemovedGroupGrace, then forgotten.
+	Lifecycle bool
+
+	// RemovedGroupGrace is how long removed groups are reported as down.
+	// If undefined, defaults to 5 minutes.
+	RemovedGroupGrace time.Duration
+
+	// MaxGroups limits how many groups are exported individually.
+	// Stats of groups beyond the limit are summed into a single group
+	// named OtherGroups, and their number is reported by
+	// groupcache_exporter_folded_groups. Once exported individually,
+	// a group remains so while listed by ListGroups. Counters of OtherGroups
+	// may go backwards as groups move in and out of it.
+	// Folded groups failing collection are left out from OtherGroups and
+	// counted in groupcache_exporter_collect_failures_total.
+	// A listed group named OtherGroups fails collection, unless folded.
+	// If undefined, there is no limit.
+	MaxGroups int
+
+	// CollectWorkers limits how many groups are collected concurrently.
+	// If undefined, groups are collected sequentially.
+	CollectWorkers int
+
+	// CollectTimeout limits how long Collect waits for each group.
+	// Groups that time out are left out from the scrape, logged and
+	// counted in groupcache_exporter_collect_timeouts_total, whose series
+	// are deleted once the group is no longer collected.
+	// While a previous collection of the group is still running, the group
+	// is counted as timed out again instead of being collected concurrently.
+	// If undefined, there is no timeout.
+	CollectTimeout time.Duration
 }
 
 // NewExporter creates Exporter.
 // namespace is usually the empty string.
+// Invalid options are reported as an invalid descriptor,
+// hence registering the Exporter fails.
 func NewExporter(options Options) *Exporter {
 
 	const subsystem = "groupcache"
@@ -60,210 +210,584 @@ func NewExporter(options Options) *Exporter {
 	namespace := options.Namespace
 	labels := options.Labels
 
-	return &Exporter{
-		options: options,
+	groupLabels := append([]string{"group"}, options.GroupLabels...)
+
+	var errs []error
+	var builtin builtinNames
+
+	var pattern *groupNamePattern
+	if options.GroupNamePattern != "" {
+		var err error
+		pattern, err = newGroupNamePattern(options.GroupNamePattern,
+			options.GroupNameFallback, labels, groupLabels)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			groupLabels = append(groupLabels, pattern.labels...)
+		}
+	}
+
+	if err := validateRules("IncludeGroups", options.IncludeGroups); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateRules("ExcludeGroups", options.ExcludeGroups); err != nil {
+		errs = append(errs, err)
+	}
+
+	typeLabels := append(slices.Clone(groupLabels), "type")
+	windowLabels := append(slices.Clone(groupLabels), "window")
+
+	if options.Supported == 0 {
+		options.Supported = AllMetrics
+	}
+	options.Supported &^= options.Disabled
+
+	var latency *latencyTracker
+	if err := validateWindows("LatencyWindows", options.LatencyWindows); err != nil {
+		errs = append(errs, err)
+	} else if len(options.LatencyWindows) > 0 {
+		latency = newLatencyTracker(options.LatencyWindows)
+	}
+
+	var derivedMetrics *derived
+	if err := validateWindows("Derived.HitRatioWindows", options.Derived.HitRatioWindows); err != nil {
+		errs = append(errs, err)
+	} else if options.Derived.enabled() {
+		derivedMetrics = newDerived(options.Derived, namespace, subsystem, labels, groupLabels, typeLabels, &builtin)
+	}
+
+	var pruner *seriesPruner
+	if options.SelfMetrics || options.CollectTimeout > 0 {
+		pruner = newSeriesPruner()
+	}
+
+	var self *selfMetrics
+	if options.SelfMetrics {
+		self = newSelfMetrics(namespace, labels, groupLabels, options.SelfMetricsBuckets, pruner, &builtin)
+	}
+
+	var timeouts *prometheus.CounterVec
+	if options.CollectTimeout > 0 {
+		timeouts = newCollectTimeouts(namespace, labels, groupLabels, &builtin)
+	}
+
+	var limiter *groupLimiter
+	if options.MaxGroups > 0 {
+		limiter = newGroupLimiter(options.MaxGroups, namespace, labels, &builtin)
+	}
+
+	var groupLifecycle *lifecycle
+	if options.Lifecycle {
+		if options.RemovedGroupGrace <= 0 {
+			options.RemovedGroupGrace = 5 * time.Minute
+		}
+		groupLifecycle = newLifecycle(options.RemovedGroupGrace, namespace, subsystem, labels, groupLabels, &builtin)
+	}
+
+	e := &Exporter{
+		lifecycle: groupLifecycle,
+		options:   options,
+		limiter:   limiter,
+		pattern:   pattern,
+		err:       errors.Join(errs...),
+		latency:   latency,
+		derived:   derivedMetrics,
+		self:      self,
+		pruner:    pruner,
+		timeouts:  timeouts,
 
 		groupGets: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "gets_total"),
+			builtin.fqName(namespace, subsystem, "gets_total"),
 			"Count of cache gets (including from peers)",
-			[]string{"group"},
+			groupLabels,
 			labels,
 		),
 		groupCacheHits: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "hits_total"),
+			builtin.fqName(namespace, subsystem, "hits_total"),
 			"Count of cache hits (from either main or hot cache)",
-			[]string{"group"},
+			groupLabels,
 			labels,
 		),
 		groupGetFromPeersLatencyLower: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "get_from_peers_latency_slowest_milliseconds"),
+			builtin.fqName(namespace, subsystem, "get_from_peers_latency_slowest_milliseconds"),
 			"Represent slowest duration to request value from peers.",
-			[]string{"group"},
+			groupLabels,
 			labels,
 		),
 		groupPeerLoads: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "peer_loads_total"),
+			builtin.fqName(namespace, subsystem, "peer_loads_total"),
 			"Count of non-error loads or cache hits from peers",
-			[]string{"group"},
+			groupLabels,
 			labels,
 		),
 		groupPeerErrors: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "peer_errors_total"),
+			builtin.fqName(namespace, subsystem, "peer_errors_total"),
 			"Count of errors from peers",
-			[]string{"group"},
+			groupLabels,
 			labels,
 		),
 		groupLoads: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "loads_total"),
+			builtin.fqName(namespace, subsystem, "loads_total"),
 			"Count of (gets - hits)",
-			[]string{"group"},
+			groupLabels,
 			labels,
 		),
 		groupLoadsDeduped: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "loads_deduped_total"),
+			builtin.fqName(namespace, subsystem, "loads_deduped_total"),
 			"Count of loads after singleflight",
-			[]string{"group"},
+			groupLabels,
 			labels,
 		),
 		groupLocalLoads: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "local_load_total"),
+			builtin.fqName(namespace, subsystem, "local_load_total"),
 			"Count of loads from local cache",
-			[]string{"group"},
+			groupLabels,
 			labels,
 		),
 		groupLocalLoadErrs: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "local_load_errs_total"),
+			builtin.fqName(namespace, subsystem, "local_load_errs_total"),
 			"Count of loads from local cache that failed",
-			[]string{"group"},
+			groupLabels,
 			labels,
 		),
 		groupServerRequests: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "server_requests_total"),
+			builtin.fqName(namespace, subsystem, "server_requests_total"),
 			"Count of gets that came over the network from peers",
-			[]string{"group"},
+			groupLabels,
 			labels,
 		),
 		groupCrosstalkRefusals: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "crosstalk_refusals_total"),
+			builtin.fqName(namespace, subsystem, "crosstalk_refusals_total"),
 			"Count of refusals for additional crosstalks",
-			[]string{"group"},
+			groupLabels,
+			labels,
+		),
+
+		groupGetFromPeersLatencyWindow: prometheus.NewDesc(
+			builtin.fqName(namespace, subsystem, "get_from_peers_latency_window_max_milliseconds"),
+			"Represent slowest duration to request value from peers within the sliding window.",
+			windowLabels,
+			labels,
+		),
+
+		collectError: prometheus.NewDesc(
+			builtin.fqName(namespace, "groupcache_exporter", "collect_error"),
+			"Failure collecting stats from a group",
+			groupLabels,
 			labels,
 		),
 
 		cacheBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "cache_bytes"),
+			builtin.fqName(namespace, subsystem, "cache_bytes"),
 			"Gauge of current bytes in use",
-			[]string{"group", "type"},
+			typeLabels,
 			labels,
 		),
 		cacheItems: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "cache_items"),
+			builtin.fqName(namespace, subsystem, "cache_items"),
 			"Gauge of current items in use",
-			[]string{"group", "type"},
+			typeLabels,
 			labels,
 		),
 		cacheGets: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "cache_gets_total"),
+			builtin.fqName(namespace, subsystem, "cache_gets_total"),
 			"Count of cache gets",
-			[]string{"group", "type"},
+			typeLabels,
 			labels,
 		),
 		cacheHits: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "cache_hits_total"),
+			builtin.fqName(namespace, subsystem, "cache_hits_total"),
 			"Count of cache hits",
-			[]string{"group", "type"},
+			typeLabels,
 			labels,
 		),
 		cacheEvictions: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "cache_evictions_total"),
+			builtin.fqName(namespace, subsystem, "cache_evictions_total"),
 			"Count of cache evictions",
-			[]string{"group", "type"},
+			typeLabels,
 			labels,
 		),
 		cacheEvictionsNonExpired: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "cache_evictions_nonexpired_total"),
+			builtin.fqName(namespace, subsystem, "cache_evictions_nonexpired_total"),
 			"Count of cache evictions for non-expired keys due to memory full.",
-			[]string{"group", "type"},
+			typeLabels,
 			labels,
 		),
 	}
+
+	e.extra = newExtraMetrics(namespace, subsystem, labels, groupLabels, builtin)
+
+	return e
 }
 
 // Describe sends metrics descriptors.
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
-	ch <- e.groupGets
-	ch <- e.groupCacheHits
-	ch <- e.groupGetFromPeersLatencyLower
-	ch <- e.groupPeerLoads
-	ch <- e.groupPeerErrors
-	ch <- e.groupLoads
-	ch <- e.groupLoadsDeduped
-	ch <- e.groupLocalLoads
-	ch <- e.groupLocalLoadErrs
-	ch <- e.groupServerRequests
-	ch <- e.groupCrosstalkRefusals
-
-	ch <- e.cacheBytes
-	ch <- e.cacheItems
-	ch <- e.cacheGets
-	ch <- e.cacheHits
-	ch <- e.cacheEvictions
-	ch <- e.cacheEvictionsNonExpired
+	if e.err != nil {
+		ch <- prometheus.NewInvalidDesc(e.err)
+		return
+	}
+	e.describeBuiltin(ch)
+	e.describeExtra(ch)
+}
+
+// describeBuiltin sends descriptors for metrics not defined by groups.
+func (e *Exporter) describeBuiltin(ch chan<- *prometheus.Desc) {
+	for _, d := range e.descriptors() {
+		if e.options.Supported.Has(d.metric) {
+			ch <- d.desc
+		}
+	}
+	if e.latency != nil && e.options.Supported.Has(MetricGetFromPeersLatencyLower) {
+		ch <- e.groupGetFromPeersLatencyWindow
+	}
+	if e.derived != nil {
+		e.derived.describe(ch, e.options.Supported)
+	}
+	if e.self != nil {
+		e.self.describe(ch)
+	}
+	if e.timeouts != nil {
+		e.timeouts.Describe(ch)
+	}
+	if e.limiter != nil {
+		e.limiter.folded.Describe(ch)
+	}
+	if e.lifecycle != nil {
+		e.lifecycle.describe(ch)
+	}
+}
+
+type descriptor struct {
+	metric Metrics
+	desc   *prometheus.Desc
+}
+
+func (e *Exporter) descriptors() []descriptor {
+	return []descriptor{
+		{MetricGets, e.groupGets},
+		{MetricHits, e.groupCacheHits},
+		{MetricGetFromPeersLatencyLower, e.groupGetFromPeersLatencyLower},
+		{MetricPeerLoads, e.groupPeerLoads},
+		{MetricPeerErrors, e.groupPeerErrors},
+		{MetricLoads, e.groupLoads},
+		{MetricLoadsDeduped, e.groupLoadsDeduped},
+		{MetricLocalLoads, e.groupLocalLoads},
+		{MetricLocalLoadsErrs, e.groupLocalLoadErrs},
+		{MetricServerRequests, e.groupServerRequests},
+		{MetricCrosstalkRefusals, e.groupCrosstalkRefusals},
+
+		{MetricCacheBytes, e.cacheBytes},
+		{MetricCacheItems, e.cacheItems},
+		{MetricCacheGets, e.cacheGets},
+		{MetricCacheHits, e.cacheHits},
+		{MetricCacheEvictions, e.cacheEvictions},
+		{MetricCacheEvictionsNonExpired, e.cacheEvictionsNonExpired},
+	}
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
 func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
-	for _, group := range e.options.ListGroups() {
-		e.collectFromGroup(ch, group)
+	if e.err != nil {
+		ch <- prometheus.NewInvalidMetric(prometheus.NewInvalidDesc(e.err), e.err)
+		return
+	}
+	if e.timeouts != nil {
+		defer e.timeouts.Collect(ch)
+	}
+	if e.limiter != nil {
+		defer e.limiter.folded.Collect(ch)
+	}
+
+	e.expire()
+
+	var scrape uint64
+	if e.pruner != nil {
+		scrape = e.pruner.begin()
+	}
+
+	begin := time.Now()
+	groups := e.options.ListGroups()
+	listed := time.Since(begin)
+
+	groups = e.filterGroups(groups)
+	groups = e.dropDuplicates(ch, groups)
+
+	var sum *totals
+	if e.options.Totals {
+		sum = newTotals()
+	}
+
+	if e.lifecycle != nil {
+		e.observeGroups(groups)
+	}
+
+	exported := e.limitGroups(groups)
+
+	e.collectGroups(ch, exported, sum)
+
+	if sum != nil {
+		e.collectTotals(ch, sum)
+	}
+
+	if e.lifecycle != nil {
+		e.lifecycle.collect(ch, e.options.Debug)
+	}
+
+	if e.pruner != nil {
+		e.pruner.prune(scrape, e.prunedVecs()...)
+	}
+
+	if e.self != nil {
+		e.self.listGroupsDuration.Set(listed.Seconds())
+		e.self.groups.Set(float64(len(groups)))
+		e.self.scrapeDuration.Set(time.Since(begin).Seconds())
+		e.self.collect(ch)
 	}
 }
 
-func (e *Exporter) collectFromGroup(ch chan<- prometheus.Metric, group GroupStatistics) {
-	stats := group.Collect()
-	groupName := group.Name()
+// prunedVecs lists the vectors labeled by group.
+func (e *Exporter) prunedVecs() []labelValuesDeleter {
+	var vecs []labelValuesDeleter
+	if e.self != nil {
+		vecs = append(vecs, e.self.collectDuration, e.self.collectFailures)
+	}
+	if e.timeouts != nil {
+		vecs = append(vecs, e.timeouts)
+	}
+	return vecs
+}
+
+// expire forgets state kept for groups no longer seen.
+func (e *Exporter) expire() {
+	if e.latency != nil {
+		e.latency.expire()
+	}
+	if e.derived != nil && e.derived.windows != nil {
+		e.derived.windows.expire()
+	}
+}
+
+// collectFromGroup recovers from a panic in the group, reporting it as
+// an invalid metric, in order to not disrupt collection of other groups.
+// Collected stats are accumulated into sum, unless nil.
+func (e *Exporter) collectFromGroup(ch chan<- prometheus.Metric, group GroupStatistics, sum *totals) {
+	var labelValues []string
+
+	defer func() {
+		if r := recover(); r != nil {
+			e.collectFailure(ch, labelValues, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	labelValues = e.labelValues(group)
+	if err := validLabelValues(labelValues); err != nil {
+		e.collectFailure(ch, labelValues, err)
+		return
+	}
+	if e.reserved(group) {
+		e.collectFailure(ch, labelValues, errReservedGroup)
+		return
+	}
+
+	begin := time.Now()
+	stats, err := e.groupStats(group, seriesKey(labelValues))
+	elapsed := time.Since(begin)
+
+	switch {
+	case errors.Is(err, errCollectTimeout):
+		slog.Warn("collectFromGroup", "group", labelValues, "error", err)
+		e.pruner.touch(labelValues)
+		e.timeouts.WithLabelValues(labelValues...).Inc()
+		return
+	case err != nil:
+		e.collectFailure(ch, labelValues, err)
+		return
+	}
+
+	if e.self != nil {
+		e.self.observeGroup(labelValues, elapsed)
+	}
 
 	if e.options.Debug {
 		slog.Info("collectFromGroup",
-			"group", groupName,
+			"group", labelValues,
 			"stats", stats,
 		)
 	}
 
-	e.collectStats(ch, stats.Group, groupName)
-	e.collectCacheStats(ch, stats.Main, groupName, "main")
-	e.collectCacheStats(ch, stats.Hot, groupName, "hot")
+	supported := e.options.Supported & SupportedBy(group)
+
+	if sum != nil {
+		sum.add(stats, supported)
+	}
+
+	e.sendGroup(ch, stats, labelValues, supported)
+	e.collectExtra(ch, group, labelValues)
+}
+
+// sendGroup sends all metrics for the group stats.
+func (e *Exporter) sendGroup(ch chan<- prometheus.Metric, stats Stats, labelValues []string, supported Metrics) {
+	e.collectStats(ch, stats.Group, labelValues, supported)
+	caches := e.cacheTypes(stats)
+	for _, c := range caches {
+		e.collectCacheStats(ch, c.stats, append(slices.Clone(labelValues), c.name), supported)
+	}
+
+	if e.derived != nil {
+		e.derived.collect(ch, e.options.Debug, stats.Group, caches, labelValues, supported)
+	}
+}
+
+// collectFailure logs the failure and reports it as an invalid metric.
+// labelValues is nil if the group failed to provide its labels.
+func (e *Exporter) collectFailure(ch chan<- prometheus.Metric, labelValues []string, err error) {
+	err = fmt.Errorf("collect group %q: %w", labelValues, err)
+
+	slog.Error("collectFromGroup", "group", labelValues, "error", err)
+
+	ch <- prometheus.NewInvalidMetric(e.collectError, err)
+
+	if e.self != nil {
+		e.self.failure(labelValues)
+	}
+}
+
+var errInvalidUTF8 = errors.New("label value is not valid UTF-8")
+
+var errReservedGroup = errors.New("group name is reserved")
+
+// reserved reports whether a listed group is named after a synthetic group
+// in use, OtherGroups or TotalGroups, whose series it would duplicate.
+func (e *Exporter) reserved(group GroupStatistics) bool {
+	if _, synthetic := group.(*otherGroup); synthetic {
+		return false
+	}
+	switch group.Name() {
+	case OtherGroups:
+		return e.limiter != nil
+	case TotalGroups:
+		return e.options.Totals
+	}
+	return false
+}
+
+func validLabelValues(labelValues []string) error {
+	for _, v := range labelValues {
+		if !utf8.ValidString(v) {
+			return errInvalidUTF8
+		}
+	}
+	return nil
+}
+
+// labelValues returns values for label group followed by Options.GroupLabels
+// and labels from Options.GroupNamePattern.
+func (e *Exporter) labelValues(group GroupStatistics) []string {
+	var groupLabels map[string]string
+	if len(e.options.GroupLabels) > 0 {
+		if gl, ok := group.(GroupLabels); ok {
+			groupLabels = gl.GroupLabels()
+		}
+	}
+	return e.labelValuesFor(group.Name(), groupLabels)
+}
+
+func (e *Exporter) labelValuesFor(name string, groupLabels map[string]string) []string {
+	values := []string{name}
+	for _, label := range e.options.GroupLabels {
+		values = append(values, groupLabels[label])
+	}
+	if e.pattern != nil {
+		values = append(values, e.pattern.values(name)...)
+	}
+	return values
 }
 
 func metric(debug bool, name string, desc *prometheus.Desc, valueType prometheus.ValueType,
-	value float64, groupName string) prometheus.Metric {
+	value float64, labelValues ...string) prometheus.Metric {
 
 	if debug {
 		slog.Info("metric",
 			"name", name,
 			"value", value,
-			"group", groupName,
+			"labels", labelValues,
 		)
 	}
 
-	return prometheus.MustNewConstMetric(desc, valueType, value, groupName)
+	m, err := prometheus.NewConstMetric(desc, valueType, value, labelValues...)
+	if err != nil {
+		slog.Error("metric", "name", name, "labels", labelValues, "error", err)
+		return prometheus.NewInvalidMetric(desc, err)
+	}
+	return m
 }
 
-func metricPerType(debug bool, name string, desc *prometheus.Desc, valueType prometheus.ValueType,
-	value float64, groupName, cacheType string) prometheus.Metric {
+func (e *Exporter) collectStats(ch chan<- prometheus.Metric, stats GroupStats, labelValues []string, supported Metrics) {
+	debug := e.options.Debug
+	send := func(m Metrics, name string, desc *prometheus.Desc, valueType prometheus.ValueType, value float64) {
+		if supported.Has(m) {
+			ch <- metric(debug, name, desc, valueType, value, labelValues...)
+		}
+	}
+	send(MetricGets, "gets", e.groupGets, prometheus.CounterValue, float64(stats.CounterGets))
+	send(MetricHits, "hits", e.groupCacheHits, prometheus.CounterValue, float64(stats.CounterHits))
+	send(MetricGetFromPeersLatencyLower, "get_from_peers_latency_slowest_milliseconds", e.groupGetFromPeersLatencyLower, prometheus.GaugeValue, stats.GaugeGetFromPeersLatencyLower)
+	send(MetricPeerLoads, "peer_loads", e.groupPeerLoads, prometheus.CounterValue, float64(stats.CounterPeerLoads))
+	send(MetricPeerErrors, "peer_errors", e.groupPeerErrors, prometheus.CounterValue, float64(stats.CounterPeerErrors))
+	send(MetricLoads, "loads", e.groupLoads, prometheus.CounterValue, float64(stats.CounterLoads))
+	send(MetricLoadsDeduped, "loads_deduped", e.groupLoadsDeduped, prometheus.CounterValue, float64(stats.CounterLoadsDeduped))
+	send(MetricLocalLoads, "local_load", e.groupLocalLoads, prometheus.CounterValue, float64(stats.CounterLocalLoads))
+	send(MetricLocalLoadsErrs, "local_load_errs", e.groupLocalLoadErrs, prometheus.CounterValue, float64(stats.CounterLocalLoadsErrs))
+	send(MetricServerRequests, "server_requests", e.groupServerRequests, prometheus.CounterValue, float64(stats.CounterServerRequests))
+	send(MetricCrosstalkRefusals, "crosstalk_refusals", e.groupCrosstalkRefusals, prometheus.CounterValue, float64(stats.CounterCrosstalkRefusals))
 
-	if debug {
-		slog.Info("metricPerType",
-			"name", name,
-			"value", value,
-			"group", groupName,
-			"type", cacheType,
-		)
+	if e.latency != nil && supported.Has(MetricGetFromPeersLatencyLower) {
+		e.collectLatencyWindows(ch, stats.GaugeGetFromPeersLatencyLower, labelValues)
 	}
+}
 
-	return prometheus.MustNewConstMetric(desc, valueType, value, groupName, cacheType)
+func (e *Exporter) collectLatencyWindows(ch chan<- prometheus.Metric, raw float64, labelValues []string) {
+	values := e.latency.observe(seriesKey(labelValues), raw)
+	for i, w := range e.latency.windows {
+		ch <- metric(e.options.Debug, "get_from_peers_latency_window_max_milliseconds",
+			e.groupGetFromPeersLatencyWindow, prometheus.GaugeValue, values[i],
+			append(slices.Clone(labelValues), windowLabel(w))...)
+	}
 }
 
-func (e *Exporter) collectStats(ch chan<- prometheus.Metric, stats GroupStats, groupName string) {
-	debug := e.options.Debug
-	ch <- metric(debug, "gets", e.groupGets, prometheus.CounterValue, float64(stats.CounterGets), groupName)
-	ch <- metric(debug, "hits", e.groupCacheHits, prometheus.CounterValue, float64(stats.CounterHits), groupName)
-	ch <- metric(debug, "get_from_peers_latency_slowest_milliseconds", e.groupGetFromPeersLatencyLower, prometheus.GaugeValue, stats.GaugeGetFromPeersLatencyLower, groupName)
-	ch <- metric(debug, "peer_loads", e.groupPeerLoads, prometheus.CounterValue, float64(stats.CounterPeerLoads), groupName)
-	ch <- metric(debug, "peer_errors", e.groupPeerErrors, prometheus.CounterValue, float64(stats.CounterPeerErrors), groupName)
-	ch <- metric(debug, "loads", e.groupLoads, prometheus.CounterValue, float64(stats.CounterLoads), groupName)
-	ch <- metric(debug, "loads_deduped", e.groupLoadsDeduped, prometheus.CounterValue, float64(stats.CounterLoadsDeduped), groupName)
-	ch <- metric(debug, "local_load", e.groupLocalLoads, prometheus.CounterValue, float64(stats.CounterLocalLoads), groupName)
-	ch <- metric(debug, "local_load_errs", e.groupLocalLoadErrs, prometheus.CounterValue, float64(stats.CounterLocalLoadsErrs), groupName)
-	ch <- metric(debug, "server_requests", e.groupServerRequests, prometheus.CounterValue, float64(stats.CounterServerRequests), groupName)
-	ch <- metric(debug, "crosstalk_refusals", e.groupCrosstalkRefusals, prometheus.CounterValue, float64(stats.CounterCrosstalkRefusals), groupName)
+// seriesKey identifies a group by its label values,
+// since group names are not unique across workspaces.
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// cacheType holds stats for one cache type under its label value.
+type cacheType struct {
+	name  string
+	stats CacheTypeStats
 }
 
-func (e *Exporter) collectCacheStats(ch chan<- prometheus.Metric, stats CacheTypeStats, groupName, cacheType string) {
+// cacheTypes returns the stats for exported cache types,
+// omitting the hot cache if Options.DisableHotCache is set.
+func (e *Exporter) cacheTypes(stats Stats) []cacheType {
+	if e.options.DisableHotCache {
+		return []cacheType{{"main", stats.Main}}
+	}
+	return []cacheType{{"main", stats.Main}, {"hot", stats.Hot}}
+}
+
+// collectCacheStats sends per-type metrics. typeLabelValues holds group labels followed by cache type.
+func (e *Exporter) collectCacheStats(ch chan<- prometheus.Metric, stats CacheTypeStats, typeLabelValues []string, supported Metrics) {
 	debug := e.options.Debug
-	ch <- metricPerType(debug, "cache_items", e.cacheItems, prometheus.GaugeValue, float64(stats.GaugeCacheItems), groupName, cacheType)
-	ch <- metricPerType(debug, "cache_bytes", e.cacheBytes, prometheus.GaugeValue, float64(stats.GaugeCacheBytes), groupName, cacheType)
-	ch <- metricPerType(debug, "cache_gets", e.cacheGets, prometheus.CounterValue, float64(stats.CounterCacheGets), groupName, cacheType)
-	ch <- metricPerType(debug, "cache_hits", e.cacheHits, prometheus.CounterValue, float64(stats.CounterCacheHits), groupName, cacheType)
-	ch <- metricPerType(debug, "cache_evictions", e.cacheEvictions, prometheus.CounterValue, float64(stats.CounterCacheEvictions), groupName, cacheType)
-	ch <- metricPerType(debug, "cache_evictions_nonexpired", e.cacheEvictionsNonExpired, prometheus.CounterValue, float64(stats.CounterCacheEvictionsNonExpired), groupName, cacheType)
+	send := func(m Metrics, name string, desc *prometheus.Desc, valueType prometheus.ValueType, value float64) {
+		if supported.Has(m) {
+			ch <- metric(debug, name, desc, valueType, value, typeLabelValues...)
+		}
+	}
+	send(MetricCacheItems, "cache_items", e.cacheItems, prometheus.GaugeValue, float64(stats.GaugeCacheItems))
+	send(MetricCacheBytes, "cache_bytes", e.cacheBytes, prometheus.GaugeValue, float64(stats.GaugeCacheBytes))
+	send(MetricCacheGets, "cache_gets", e.cacheGets, prometheus.CounterValue, float64(stats.CounterCacheGets))
+	send(MetricCacheHits, "cache_hits", e.cacheHits, prometheus.CounterValue, float64(stats.CounterCacheHits))
+	send(MetricCacheEvictions, "cache_evictions", e.cacheEvictions, prometheus.CounterValue, float64(stats.CounterCacheEvictions))
+	send(MetricCacheEvictionsNonExpired, "cache_evictions_nonexpired", e.cacheEvictionsNonExpired, prometheus.CounterValue, float64(stats.CounterCacheEvictionsNonExpired))
 }
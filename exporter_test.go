@@ -0,0 +1,183 @@
+package groupcache_exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// fakeGroup implements GroupStatistics for testing.
+type fakeGroup struct {
+	name      string
+	stats     Stats
+	supported Metrics
+}
+
+func (g *fakeGroup) Collect() Stats { return g.stats }
+
+func (g *fakeGroup) Name() string { return g.name }
+
+func (g *fakeGroup) Supported() Metrics {
+	if g.supported == 0 {
+		return AllMetrics
+	}
+	return g.supported
+}
+
+func listGroups(groups ...GroupStatistics) func() []GroupStatistics {
+	return func() []GroupStatistics { return groups }
+}
+
+// go test -count 1 -run '^TestSupportedMetrics$' ./...
+func TestSupportedMetrics(t *testing.T) {
+	g := &fakeGroup{
+		name:      "group1",
+		supported: AllMetrics &^ (MetricCrosstalkRefusals | MetricCacheEvictionsNonExpired),
+	}
+
+	e := NewExporter(Options{ListGroups: listGroups(g)})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(e)
+
+	for _, name := range []string{
+		"groupcache_crosstalk_refusals_total",
+		"groupcache_cache_evictions_nonexpired_total",
+	} {
+		if count := testutil.CollectAndCount(e, name); count != 0 {
+			t.Errorf("unsupported metric %s: expected 0 series, got %d", name, count)
+		}
+	}
+
+	if count := testutil.CollectAndCount(e, "groupcache_gets_total"); count != 1 {
+		t.Errorf("gets: expected 1 series, got %d", count)
+	}
+
+	if count := testutil.CollectAndCount(e, "groupcache_cache_items"); count != 2 {
+		t.Errorf("cache items: expected 2 series, got %d", count)
+	}
+}
+
+// go test -count 1 -run '^TestSupportedMetricsDescribe$' ./...
+func TestSupportedMetricsDescribe(t *testing.T) {
+	e := NewExporter(Options{
+		ListGroups: listGroups(),
+		Supported:  MetricGets | MetricHits,
+	})
+
+	ch := make(chan *prometheus.Desc, 100)
+	e.Describe(ch)
+	close(ch)
+
+	if len(ch) != 2 {
+		t.Errorf("expected 2 descriptors, got %d", len(ch))
+	}
+}
+
+// go test -count 1 -run '^TestLatencyWindows$' ./...
+func TestLatencyWindows(t *testing.T) {
+	withLatency := &fakeGroup{name: "group1"}
+	withoutLatency := &fakeGroup{name: "group2", supported: AllMetrics &^ MetricGetFromPeersLatencyLower}
+
+	e := NewExporter(Options{
+		ListGroups:     listGroups(withLatency, withoutLatency),
+		LatencyWindows: []time.Duration{time.Minute, 5 * time.Minute},
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(e)
+
+	const name = "groupcache_get_from_peers_latency_window_max_milliseconds"
+	if count := testutil.CollectAndCount(e, name); count != 2 {
+		t.Errorf("expected 2 window series, got %d", count)
+	}
+}
+
+// go test -count 1 -run '^TestMetricsNames$' ./...
+func TestMetricsNames(t *testing.T) {
+	names := AllMetrics.Names()
+	if len(names) != 17 {
+		t.Errorf("expected 17 names, got %d", len(names))
+	}
+	s, unknown := ParseMetrics(append(names, "bogus"))
+	if s != AllMetrics {
+		t.Errorf("expected AllMetrics, got %b", s)
+	}
+	if len(unknown) != 1 || unknown[0] != "bogus" {
+		t.Errorf("expected unknown bogus, got %v", unknown)
+	}
+}
+
+// panicGroup panics on Collect.
+type panicGroup struct{ fakeGroup }
+
+func (g *panicGroup) Collect() Stats { panic("boom") }
+
+// go test -count 1 -run '^TestCollectFailures$' ./...
+func TestCollectFailures(t *testing.T) {
+	e := NewExporter(Options{
+		ListGroups: listGroups(
+			&fakeGroup{name: "group1"},
+			&panicGroup{fakeGroup{name: "group2"}},
+			&fakeGroup{name: "bad\xffname"},
+		),
+		SelfMetrics: true,
+	})
+
+	ch := make(chan prometheus.Metric, 1000)
+	e.Collect(ch)
+	close(ch)
+
+	var invalid int
+	gets := map[string]bool{}
+	for m := range ch {
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			invalid++
+			continue
+		}
+		if strings.HasPrefix(m.Desc().String(), `Desc{fqName: "groupcache_gets_total"`) {
+			gets[out.GetLabel()[0].GetValue()] = true
+		}
+	}
+
+	if invalid != 2 {
+		t.Errorf("expected 2 invalid metrics, got %d", invalid)
+	}
+	if len(gets) != 1 || !gets["group1"] {
+		t.Errorf("expected gets only for group1, got %v", gets)
+	}
+	if count := testutil.CollectAndCount(e.self.collectFailures); count != 2 {
+		t.Errorf("expected 2 failure series, got %d", count)
+	}
+}
+
+// labeledGroup implements GroupLabels.
+type labeledGroup struct {
+	fakeGroup
+	labels map[string]string
+}
+
+func (g *labeledGroup) GroupLabels() map[string]string { return g.labels }
+
+// go test -count 1 -run '^TestGroupKey$' ./...
+func TestGroupKey(t *testing.T) {
+	ws1 := &labeledGroup{fakeGroup{name: "files"}, map[string]string{"workspace": "ws1", "zone": "a"}}
+	ws1Again := &labeledGroup{fakeGroup{name: "files"}, map[string]string{"zone": "a", "workspace": "ws1"}}
+	ws2 := &labeledGroup{fakeGroup{name: "files"}, map[string]string{"workspace": "ws2", "zone": "a"}}
+	plain := &fakeGroup{name: "files"}
+
+	if GroupKey(ws1) != GroupKey(ws1Again) {
+		t.Errorf("expected same key for same labels")
+	}
+	if GroupKey(ws1) == GroupKey(ws2) {
+		t.Errorf("expected distinct keys for distinct workspaces")
+	}
+	if GroupKey(plain) != "files" {
+		t.Errorf("expected name as key for group without labels, got %q", GroupKey(plain))
+	}
+}
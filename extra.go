@@ -0,0 +1,187 @@
+package groupcache_exporter
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ExtraMetricType is the type of an ExtraMetric.
+type ExtraMetricType int
+
+// Types of ExtraMetric.
+const (
+	ExtraCounter ExtraMetricType = iota
+	ExtraGauge
+)
+
+// ExtraMetric is a custom metric reported by a group.
+type ExtraMetric struct {
+	// Name is the metric name without namespace and subsystem,
+	// like ttl_expirations_total.
+	Name  string
+	Help  string
+	Type  ExtraMetricType
+	Value float64
+}
+
+// ExtraMetrics is an optional interface for GroupStatistics.
+// An implementation may implement it in order to export custom metrics
+// that do not fit in Stats. They are exported under the same namespace,
+// subsystem and labels as metrics from Stats, and are not included
+// in groups OtherGroups and TotalGroups. Every group reporting a metric
+// name must report the same help and type. Names clashing with metrics
+// exported by Exporter are rejected as invalid metrics.
+//
+// Exporter describes extra metrics reported by the groups listed when
+// the Exporter is registered. Metrics first reported afterwards are
+// undescribed: regular registries accept them, but pedantic registries
+// reject them.
+type ExtraMetrics interface {
+	// ExtraMetrics returns the custom metrics for the group.
+	ExtraMetrics() []ExtraMetric
+}
+
+// extraMetrics creates and caches descriptors for extra metrics.
+type extraMetrics struct {
+	namespace   string
+	subsystem   string
+	labels      map[string]string
+	groupLabels []string
+	builtin     []string // fully-qualified names of built-in metrics
+
+	mutex sync.Mutex
+	descs map[string]extraDesc
+}
+
+type extraDesc struct {
+	desc *prometheus.Desc
+	help string
+	typ  ExtraMetricType
+	err  error
+}
+
+func newExtraMetrics(namespace, subsystem string, labels map[string]string, groupLabels,
+	builtin []string) *extraMetrics {
+	return &extraMetrics{
+		namespace:   namespace,
+		subsystem:   subsystem,
+		labels:      labels,
+		groupLabels: groupLabels,
+		builtin:     builtin,
+		descs:       map[string]extraDesc{},
+	}
+}
+
+// desc returns the descriptor for the metric, or an error if the metric
+// clashes with a built-in metric or disagrees with a previous help or type.
+func (x *extraMetrics) desc(m ExtraMetric) (*prometheus.Desc, error) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	d, found := x.descs[m.Name]
+	if !found {
+		fqName := prometheus.BuildFQName(x.namespace, x.subsystem, m.Name)
+		d = extraDesc{
+			desc: prometheus.NewDesc(fqName, m.Help, x.groupLabels, x.labels),
+			help: m.Help,
+			typ:  m.Type,
+		}
+		switch {
+		case slices.Contains(x.builtin, fqName):
+			d.err = fmt.Errorf("extra metric %q clashes with built-in metric", fqName)
+		case m.Type != ExtraCounter && m.Type != ExtraGauge:
+			d.err = fmt.Errorf("extra metric %q: invalid type %d", fqName, m.Type)
+		}
+		x.descs[m.Name] = d
+	}
+
+	if d.err != nil {
+		return d.desc, d.err
+	}
+	if m.Help != d.help || m.Type != d.typ {
+		return d.desc, fmt.Errorf("extra metric %q: help or type differs from previously reported", m.Name)
+	}
+	return d.desc, nil
+}
+
+// describe sends descriptors for the extra metrics currently reported by groups.
+func (x *extraMetrics) describe(ch chan<- *prometheus.Desc, groups []GroupStatistics) {
+	seen := map[string]bool{}
+	for _, g := range groups {
+		em, ok := g.(ExtraMetrics)
+		if !ok {
+			continue
+		}
+		for _, m := range safeExtraMetrics(em) {
+			if seen[m.Name] {
+				continue
+			}
+			seen[m.Name] = true
+			desc, err := x.desc(m)
+			if err != nil {
+				ch <- prometheus.NewInvalidDesc(err)
+				continue
+			}
+			ch <- desc
+		}
+	}
+}
+
+// collectExtra sends extra metrics reported by the group.
+func (e *Exporter) collectExtra(ch chan<- prometheus.Metric, group GroupStatistics, labelValues []string) {
+	em, ok := group.(ExtraMetrics)
+	if !ok {
+		return
+	}
+	for _, m := range em.ExtraMetrics() {
+		desc, err := e.extra.desc(m)
+		if err != nil {
+			slog.Error("collectExtra", "group", labelValues, "error", err)
+			ch <- prometheus.NewInvalidMetric(desc, err)
+			continue
+		}
+		valueType := prometheus.CounterValue
+		if m.Type == ExtraGauge {
+			valueType = prometheus.GaugeValue
+		}
+		ch <- metric(e.options.Debug, m.Name, desc, valueType, m.Value, labelValues...)
+	}
+}
+
+// safeExtraMetrics returns the extra metrics of the group,
+// recovering from a panic in the group.
+func safeExtraMetrics(em ExtraMetrics) (metrics []ExtraMetric) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("describeExtra", "error", fmt.Errorf("panic: %v", r))
+			metrics = nil
+		}
+	}()
+	return em.ExtraMetrics()
+}
+
+// describeExtra sends descriptors for extra metrics of the groups listed,
+// recovering from a panic in ListGroups.
+func (e *Exporter) describeExtra(ch chan<- *prometheus.Desc) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("describeExtra", "error", fmt.Errorf("panic: %v", r))
+		}
+	}()
+	e.extra.describe(ch, e.filterGroups(e.options.ListGroups()))
+}
+
+// builtinNames records the fully-qualified names of metrics exported
+// by Exporter, in order to reject extra metrics clashing with them.
+type builtinNames []string
+
+// fqName builds and records a fully-qualified metric name.
+func (n *builtinNames) fqName(namespace, subsystem, name string) string {
+	fqName := prometheus.BuildFQName(namespace, subsystem, name)
+	*n = append(*n, fqName)
+	return fqName
+}
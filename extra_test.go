@@ -0,0 +1,140 @@
+package groupcache_exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// extraGroup implements ExtraMetrics.
+type extraGroup struct {
+	fakeGroup
+	extra []ExtraMetric
+}
+
+func (g *extraGroup) ExtraMetrics() []ExtraMetric { return g.extra }
+
+// go test -count 1 -run '^TestExtraMetrics$' ./...
+func TestExtraMetrics(t *testing.T) {
+	g1 := &extraGroup{fakeGroup: fakeGroup{name: "group1"}, extra: []ExtraMetric{
+		{Name: "ttl_expirations_total", Help: "Count of TTL expirations", Type: ExtraCounter, Value: 3},
+		{Name: "admission_window_items", Help: "Items in admission window", Type: ExtraGauge, Value: 7},
+	}}
+	g2 := &extraGroup{fakeGroup: fakeGroup{name: "group2"}, extra: []ExtraMetric{
+		{Name: "ttl_expirations_total", Help: "Count of TTL expirations", Type: ExtraCounter, Value: 5},
+	}}
+
+	e := NewExporter(Options{
+		ListGroups: listGroups(g1, g2, &fakeGroup{name: "group3"}),
+		Labels:     map[string]string{"app": "test"},
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(e)
+
+	expected := `
+# HELP groupcache_ttl_expirations_total Count of TTL expirations
+# TYPE groupcache_ttl_expirations_total counter
+groupcache_ttl_expirations_total{app="test",group="group1"} 3
+groupcache_ttl_expirations_total{app="test",group="group2"} 5
+# HELP groupcache_admission_window_items Items in admission window
+# TYPE groupcache_admission_window_items gauge
+groupcache_admission_window_items{app="test",group="group1"} 7
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"groupcache_ttl_expirations_total", "groupcache_admission_window_items"); err != nil {
+		t.Error(err)
+	}
+}
+
+// go test -count 1 -run '^TestExtraMetricsRejected$' ./...
+func TestExtraMetricsRejected(t *testing.T) {
+	g1 := &extraGroup{fakeGroup: fakeGroup{name: "group1"}, extra: []ExtraMetric{
+		{Name: "gets_total", Help: "Clashes with built-in", Type: ExtraCounter, Value: 1},
+		{Name: "rejections_total", Help: "Count of rejections", Type: ExtraCounter, Value: 2},
+	}}
+	g2 := &extraGroup{fakeGroup: fakeGroup{name: "group2"}, extra: []ExtraMetric{
+		{Name: "rejections_total", Help: "Count of rejections", Type: ExtraGauge, Value: 3},
+	}}
+
+	e := NewExporter(Options{ListGroups: listGroups(g1, g2)})
+
+	if err := prometheus.NewRegistry().Register(e); err == nil {
+		t.Errorf("expected registration error for clashing name")
+	}
+
+	ch := make(chan prometheus.Metric, 1000)
+	e.Collect(ch)
+	close(ch)
+
+	var invalid, rejections int
+	for m := range ch {
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			invalid++
+			continue
+		}
+		if strings.Contains(m.Desc().String(), `"groupcache_rejections_total"`) {
+			rejections++
+		}
+	}
+	if invalid != 2 {
+		t.Errorf("expected 2 invalid metrics, got %d", invalid)
+	}
+	if rejections != 1 {
+		t.Errorf("expected rejections only for group1, got %d", rejections)
+	}
+}
+
+// go test -count 1 -run '^TestExtraMetricsClashSelf$' ./...
+func TestExtraMetricsClashSelf(t *testing.T) {
+	g := &extraGroup{fakeGroup: fakeGroup{name: "group1"}, extra: []ExtraMetric{
+		{Name: "exporter_groups", Help: "Clashes with self metric", Type: ExtraGauge, Value: 1},
+	}}
+
+	e := NewExporter(Options{ListGroups: listGroups(g), SelfMetrics: true})
+
+	if err := prometheus.NewRegistry().Register(e); err == nil {
+		t.Errorf("expected registration error for name clashing with self metric")
+	}
+}
+
+// panicExtraGroup panics when asked for extra metrics.
+type panicExtraGroup struct{ fakeGroup }
+
+func (g *panicExtraGroup) ExtraMetrics() []ExtraMetric { panic("boom") }
+
+// go test -count 1 -run '^TestExtraMetricsDescribePanic$' ./...
+func TestExtraMetricsDescribePanic(t *testing.T) {
+	g := &extraGroup{fakeGroup: fakeGroup{name: "group1"}, extra: []ExtraMetric{
+		{Name: "ttl_expirations_total", Help: "Count of TTL expirations", Type: ExtraCounter, Value: 3},
+	}}
+
+	e := NewExporter(Options{ListGroups: listGroups(&panicExtraGroup{fakeGroup{name: "group2"}}, g)})
+
+	if err := prometheus.NewPedanticRegistry().Register(e); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ch := make(chan *prometheus.Desc, 1000)
+	e.Describe(ch)
+	close(ch)
+
+	var described bool
+	for d := range ch {
+		if strings.Contains(d.String(), `"groupcache_ttl_expirations_total"`) {
+			described = true
+		}
+	}
+	if !described {
+		t.Errorf("expected extra metric of group1 described")
+	}
+
+	failing := NewExporter(Options{ListGroups: func() []GroupStatistics { panic("boom") }})
+	if err := prometheus.NewRegistry().Register(failing); err != nil {
+		t.Errorf("register with panicking ListGroups: %v", err)
+	}
+}
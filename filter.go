@@ -0,0 +1,84 @@
+package groupcache_exporter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// GroupRule matches group names either by Glob, using the syntax of path.Match,
+// or by Regexp. A rule defining both matches if either matches.
+// A malformed Glob is rejected by NewExporter.
+type GroupRule struct {
+	Glob   string
+	Regexp *regexp.Regexp
+}
+
+// Match reports whether the rule matches the group name.
+func (r GroupRule) Match(name string) bool {
+	if r.Glob != "" {
+		if found, _ := path.Match(r.Glob, name); found {
+			return true
+		}
+	}
+	return r.Regexp != nil && r.Regexp.MatchString(name)
+}
+
+// validateRules rejects malformed globs, which would otherwise match nothing.
+func validateRules(option string, rules []GroupRule) error {
+	for _, r := range rules {
+		if r.Glob == "" {
+			continue
+		}
+		if _, err := path.Match(r.Glob, ""); err != nil {
+			return fmt.Errorf("%s: glob %q: %w", option, r.Glob, err)
+		}
+	}
+	return nil
+}
+
+func matchAny(rules []GroupRule, name string) bool {
+	for _, r := range rules {
+		if r.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterGroups keeps groups matching Options.IncludeGroups, if defined,
+// and not matching Options.ExcludeGroups.
+func (e *Exporter) filterGroups(groups []GroupStatistics) []GroupStatistics {
+	include := e.options.IncludeGroups
+	exclude := e.options.ExcludeGroups
+	if len(include) == 0 && len(exclude) == 0 {
+		return groups
+	}
+	var result []GroupStatistics
+	for _, g := range groups {
+		name, ok := safeName(g)
+		if !ok {
+			// keep it, so collectFromGroup reports the failure
+			result = append(result, g)
+			continue
+		}
+		if len(include) > 0 && !matchAny(include, name) {
+			continue
+		}
+		if matchAny(exclude, name) {
+			continue
+		}
+		result = append(result, g)
+	}
+	return result
+}
+
+// safeName returns the group name, recovering from a panic in the group.
+func safeName(group GroupStatistics) (name string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+		}
+	}()
+	return group.Name(), true
+}
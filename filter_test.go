@@ -0,0 +1,123 @@
+package groupcache_exporter
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// go test -count 1 -run '^TestGroupRule$' ./...
+func TestGroupRule(t *testing.T) {
+	cases := []struct {
+		rule     GroupRule
+		name     string
+		expected bool
+	}{
+		{GroupRule{Glob: "tmp-*"}, "tmp-123", true},
+		{GroupRule{Glob: "tmp-*"}, "files", false},
+		{GroupRule{Regexp: regexp.MustCompile(`^tenant-\d+$`)}, "tenant-7", true},
+		{GroupRule{Regexp: regexp.MustCompile(`^tenant-\d+$`)}, "tenant-x", false},
+		{GroupRule{Glob: "a*", Regexp: regexp.MustCompile(`^b`)}, "b", true},
+		{GroupRule{}, "files", false},
+	}
+	for _, c := range cases {
+		if got := c.rule.Match(c.name); got != c.expected {
+			t.Errorf("rule %+v name %q: expected %t, got %t", c.rule, c.name, c.expected, got)
+		}
+	}
+}
+
+// go test -count 1 -run '^TestFilterGroups$' ./...
+func TestFilterGroups(t *testing.T) {
+	e := NewExporter(Options{
+		ListGroups: listGroups(
+			&fakeGroup{name: "files"},
+			&fakeGroup{name: "tenant-1"},
+			&fakeGroup{name: "tenant-internal"},
+			&fakeGroup{name: "other"},
+		),
+		IncludeGroups: []GroupRule{{Glob: "files"}, {Glob: "tenant-*"}},
+		ExcludeGroups: []GroupRule{{Regexp: regexp.MustCompile("internal")}},
+	})
+
+	if count := testutil.CollectAndCount(e, "groupcache_gets_total"); count != 2 {
+		t.Errorf("expected 2 groups, got %d", count)
+	}
+}
+
+// go test -count 1 -run '^TestMetricToggles$' ./...
+func TestMetricToggles(t *testing.T) {
+	e := NewExporter(Options{
+		ListGroups:      listGroups(&fakeGroup{name: "files"}),
+		Disabled:        MetricCrosstalkRefusals,
+		DisableHotCache: true,
+		Derived:         DerivedOptions{HitRatio: true},
+	})
+
+	for name, expected := range map[string]int{
+		"groupcache_crosstalk_refusals_total": 0,
+		"groupcache_gets_total":               1,
+		"groupcache_cache_items":              1,
+		"groupcache_cache_hit_ratio":          1,
+	} {
+		if count := testutil.CollectAndCount(e, name); count != expected {
+			t.Errorf("%s: expected %d series, got %d", name, expected, count)
+		}
+	}
+}
+
+// go test -count 1 -run '^TestGroupRuleInvalid$' ./...
+func TestGroupRuleInvalid(t *testing.T) {
+	for _, options := range []Options{
+		{IncludeGroups: []GroupRule{{Glob: "[bad"}}},
+		{ExcludeGroups: []GroupRule{{Glob: "tmp-*"}, {Glob: "[bad"}}},
+	} {
+		options.ListGroups = listGroups()
+		e := NewExporter(options)
+		if err := prometheus.NewRegistry().Register(e); err == nil {
+			t.Errorf("rules %+v %+v: expected registration error", options.IncludeGroups, options.ExcludeGroups)
+		}
+	}
+}
+
+// panicNameGroup panics when asked for its name.
+type panicNameGroup struct{ fakeGroup }
+
+func (g *panicNameGroup) Name() string { panic("boom") }
+
+// go test -count 1 -run '^TestFilterGroupsPanic$' ./...
+func TestFilterGroupsPanic(t *testing.T) {
+	files := &fakeGroup{name: "files"}
+	files.stats.Group.CounterGets = 1
+
+	e := NewExporter(Options{
+		ListGroups:    listGroups(&panicNameGroup{}, files),
+		IncludeGroups: []GroupRule{{Glob: "files"}},
+	})
+
+	if gets, invalid := collectGets(e); gets != 1 || invalid != 1 {
+		t.Errorf("expected gets for files and 1 failure, got %d gets and %d failures", gets, invalid)
+	}
+}
+
+// collectGets collects e, counting gets series and invalid metrics.
+func collectGets(e *Exporter) (gets, invalid int) {
+	ch := make(chan prometheus.Metric, 1000)
+	e.Collect(ch)
+	close(ch)
+	for m := range ch {
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			invalid++
+			continue
+		}
+		if strings.Contains(m.Desc().String(), `"groupcache_gets_total"`) {
+			gets++
+		}
+	}
+	return gets, invalid
+}
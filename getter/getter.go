@@ -0,0 +1,106 @@
+// Package getter records load metrics for groupcache getters.
+// Subpackages google, mailgun and modernprogram wrap the Getter
+// of each groupcache implementation in order to feed Metrics.
+package getter
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Options define parameters for Metrics.
+type Options struct {
+	// Namespace and Labels should match those given to groupcache_exporter.Options.
+	Namespace string
+	Labels    map[string]string
+
+	// Registerer registers the metrics. If undefined, defaults to prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+
+	// DurationBuckets defines buckets for load duration in seconds.
+	// If undefined, defaults to prometheus.DefBuckets.
+	DurationBuckets []float64
+
+	// SizeBuckets defines buckets for loaded value size in bytes.
+	// If undefined, defaults to exponential buckets from 64 bytes to 16 MiB.
+	SizeBuckets []float64
+}
+
+// Metrics holds load metrics for groupcache getters.
+type Metrics struct {
+	loadDuration *prometheus.HistogramVec
+	loadErrors   *prometheus.CounterVec
+	valueSize    *prometheus.HistogramVec
+}
+
+// New creates Metrics and registers them.
+func New(options Options) *Metrics {
+
+	const subsystem = "groupcache"
+
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	durationBuckets := options.DurationBuckets
+	if len(durationBuckets) == 0 {
+		durationBuckets = prometheus.DefBuckets
+	}
+
+	sizeBuckets := options.SizeBuckets
+	if len(sizeBuckets) == 0 {
+		sizeBuckets = prometheus.ExponentialBuckets(64, 4, 10)
+	}
+
+	m := &Metrics{
+		loadDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   options.Namespace,
+				Subsystem:   subsystem,
+				Name:        "getter_load_duration_seconds",
+				Help:        "Duration of loads performed by the getter",
+				ConstLabels: options.Labels,
+				Buckets:     durationBuckets,
+			},
+			[]string{"group"},
+		),
+		loadErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   options.Namespace,
+				Subsystem:   subsystem,
+				Name:        "getter_load_errors_total",
+				Help:        "Count of loads performed by the getter that failed",
+				ConstLabels: options.Labels,
+			},
+			[]string{"group"},
+		),
+		valueSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   options.Namespace,
+				Subsystem:   subsystem,
+				Name:        "getter_value_bytes",
+				Help:        "Size of values loaded by the getter",
+				ConstLabels: options.Labels,
+				Buckets:     sizeBuckets,
+			},
+			[]string{"group"},
+		),
+	}
+
+	registerer.MustRegister(m.loadDuration, m.loadErrors, m.valueSize)
+
+	return m
+}
+
+// Observe records a single load for the group.
+// size is the number of bytes stored into the sink, ignored on error.
+func (m *Metrics) Observe(groupName string, elapsed time.Duration, size int, err error) {
+	m.loadDuration.WithLabelValues(groupName).Observe(elapsed.Seconds())
+	if err != nil {
+		m.loadErrors.WithLabelValues(groupName).Inc()
+		return
+	}
+	m.valueSize.WithLabelValues(groupName).Observe(float64(size))
+}
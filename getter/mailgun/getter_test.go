@@ -0,0 +1,74 @@
+package mailgun
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mailgun/groupcache/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/udhos/groupcache_exporter/getter"
+)
+
+// go test -count 1 -run '^TestWrapMailgun$' ./...
+func TestWrapMailgun(t *testing.T) {
+
+	registry := prometheus.NewPedanticRegistry()
+
+	m := getter.New(getter.Options{Registerer: registry})
+
+	const groupName = "getter-mailgun"
+
+	g := groupcache.NewGroup(groupName, 1_000_000, Wrap(m, groupName, groupcache.GetterFunc(
+		func(_ context.Context, key string, dest groupcache.Sink) error {
+			if key == "bad" {
+				return errors.New("bad key")
+			}
+			return dest.SetString("hello", time.Time{})
+		}),
+	))
+
+	var value string
+	if err := g.Get(context.TODO(), "good", groupcache.StringSink(&value)); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if err := g.Get(context.TODO(), "bad", groupcache.StringSink(&value)); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	if count := testutil.CollectAndCount(registry, "groupcache_getter_load_duration_seconds"); count != 1 {
+		t.Errorf("expected 1 duration series, got %d", count)
+	}
+
+	expected := `
+# HELP groupcache_getter_load_errors_total Count of loads performed by the getter that failed
+# TYPE groupcache_getter_load_errors_total counter
+groupcache_getter_load_errors_total{group="getter-mailgun"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "groupcache_getter_load_errors_total"); err != nil {
+		t.Errorf("load errors: %v", err)
+	}
+
+	families, errGather := registry.Gather()
+	if errGather != nil {
+		t.Fatalf("gather: %v", errGather)
+	}
+	var found bool
+	for _, f := range families {
+		if f.GetName() != "groupcache_getter_value_bytes" {
+			continue
+		}
+		found = true
+		h := f.GetMetric()[0].GetHistogram()
+		if h.GetSampleCount() != 1 || h.GetSampleSum() != 5 {
+			t.Errorf("value bytes: expected 1 sample of 5 bytes, got count=%d sum=%v",
+				h.GetSampleCount(), h.GetSampleSum())
+		}
+	}
+	if !found {
+		t.Errorf("value bytes: groupcache_getter_value_bytes not gathered")
+	}
+}
@@ -0,0 +1,43 @@
+// Package modernprogram instruments getters for modernprogram groupcache.
+package modernprogram
+
+import (
+	"context"
+	"time"
+
+	"github.com/modernprogram/groupcache/v2"
+	"github.com/udhos/groupcache_exporter/getter"
+	"google.golang.org/protobuf/proto"
+)
+
+// Wrap returns a Getter that records load metrics for the group into m.
+func Wrap(m *getter.Metrics, groupName string, g groupcache.Getter) groupcache.Getter {
+	return groupcache.GetterFunc(func(ctx context.Context, key string, dest groupcache.Sink, info *groupcache.Info) error {
+		sink := &sizeSink{Sink: dest}
+		begin := time.Now()
+		err := g.Get(ctx, key, sink, info)
+		m.Observe(groupName, time.Since(begin), sink.size, err)
+		return err
+	})
+}
+
+// sizeSink records the size of the value stored into the underlying sink.
+type sizeSink struct {
+	groupcache.Sink
+	size int
+}
+
+func (s *sizeSink) SetString(v string, e time.Time) error {
+	s.size = len(v)
+	return s.Sink.SetString(v, e)
+}
+
+func (s *sizeSink) SetBytes(v []byte, e time.Time) error {
+	s.size = len(v)
+	return s.Sink.SetBytes(v, e)
+}
+
+func (s *sizeSink) SetProto(m proto.Message, e time.Time) error {
+	s.size = proto.Size(m)
+	return s.Sink.SetProto(m, e)
+}
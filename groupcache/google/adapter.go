@@ -7,7 +7,8 @@ import (
 )
 
 // ListGroups issues an static list of exporter groups for groupcache groups.
-// Other groupcache implementations are able to issue current list, but this is not.
+// google groupcache is unable to list its groups, see RegisterGroupHook
+// and ListAllGroups in order to issue current list.
 func ListGroups(groups []*groupcache.Group) []groupcache_exporter.GroupStatistics {
 	var exportGroups []groupcache_exporter.GroupStatistics
 	for _, g := range groups {
@@ -16,6 +17,14 @@ func ListGroups(groups []*groupcache.Group) []groupcache_exporter.GroupStatistic
 	return exportGroups
 }
 
+// Supported lists the metric families available from google groupcache.
+// google groupcache does not track peer latency, crosstalk refusals
+// nor evictions of non-expired keys.
+const Supported = groupcache_exporter.AllMetrics &^
+	(groupcache_exporter.MetricGetFromPeersLatencyLower |
+		groupcache_exporter.MetricCrosstalkRefusals |
+		groupcache_exporter.MetricCacheEvictionsNonExpired)
+
 // exportGroup implements interface GroupStatistics to extract metrics from groupcache group.
 type exportGroup struct {
 	group *groupcache.Group
@@ -56,6 +65,11 @@ func getCacheStats(cacheStats groupcache.CacheStats) groupcache_exporter.CacheTy
 	}
 }
 
+// Supported returns the metric families filled by Collect.
+func (g *exportGroup) Supported() groupcache_exporter.Metrics {
+	return Supported
+}
+
 // Name returns the group's name
 func (g *exportGroup) Name() string {
 	return g.group.Name()
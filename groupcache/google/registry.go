@@ -0,0 +1,49 @@
+package google
+
+import (
+	"sync"
+
+	"github.com/golang/groupcache"
+	"github.com/udhos/groupcache_exporter"
+)
+
+var (
+	registryMutex  sync.Mutex
+	registryGroups []*groupcache.Group
+	registryOnce   sync.Once
+)
+
+// RegisterGroupHook tracks every group created afterwards by google groupcache,
+// in order to have ListAllGroups report them.
+// It registers a hook with groupcache.RegisterNewGroupHook, so it should be
+// called before creating groups. Calling it more than once is harmless.
+// If the application already registers its own hook, groupcache would panic;
+// call TrackGroup from the application hook instead.
+func RegisterGroupHook() {
+	registryOnce.Do(func() {
+		groupcache.RegisterNewGroupHook(TrackGroup)
+	})
+}
+
+// TrackGroup adds a group to the list reported by ListAllGroups.
+// Groups already tracked are ignored.
+func TrackGroup(g *groupcache.Group) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	for _, tracked := range registryGroups {
+		if tracked == g {
+			return
+		}
+	}
+	registryGroups = append(registryGroups, g)
+}
+
+// ListAllGroups issues current list of exporter groups for every group
+// tracked either by RegisterGroupHook or TrackGroup.
+func ListAllGroups() []groupcache_exporter.GroupStatistics {
+	registryMutex.Lock()
+	groups := make([]*groupcache.Group, len(registryGroups))
+	copy(groups, registryGroups)
+	registryMutex.Unlock()
+	return ListGroups(groups)
+}
@@ -0,0 +1,35 @@
+package google
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/golang/groupcache"
+)
+
+// go test -count 1 -run '^TestRegistry$' ./...
+func TestRegistry(t *testing.T) {
+
+	RegisterGroupHook()
+	RegisterGroupHook() // must not panic
+
+	getter := groupcache.GetterFunc(
+		func(_ /*ctx*/ context.Context, _ string, _ groupcache.Sink) error {
+			return nil
+		})
+
+	groupcache.NewGroup("registry1", 1_000_000, getter)
+	groupcache.NewGroup("registry2", 1_000_000, getter)
+
+	var names []string
+	for _, g := range ListAllGroups() {
+		names = append(names, g.Name())
+	}
+
+	for _, name := range []string{"registry1", "registry2"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("missing group %s in %v", name, names)
+		}
+	}
+}
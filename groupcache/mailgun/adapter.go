@@ -16,6 +16,13 @@ func ListGroups() []groupcache_exporter.GroupStatistics {
 	return exportGroups
 }
 
+// Supported lists the metric families available from mailgun groupcache.
+// mailgun groupcache does not track crosstalk refusals
+// nor evictions of non-expired keys.
+const Supported = groupcache_exporter.AllMetrics &^
+	(groupcache_exporter.MetricCrosstalkRefusals |
+		groupcache_exporter.MetricCacheEvictionsNonExpired)
+
 // exportGroup implements interface GroupStatistics to extract metrics from groupcache group.
 type exportGroup struct {
 	group *groupcache.Group
@@ -57,6 +64,11 @@ func getCacheStats(cacheStats groupcache.CacheStats) groupcache_exporter.CacheTy
 	}
 }
 
+// Supported returns the metric families filled by Collect.
+func (g *exportGroup) Supported() groupcache_exporter.Metrics {
+	return Supported
+}
+
 // Name returns the group's name
 func (g *exportGroup) Name() string {
 	return g.group.Name()
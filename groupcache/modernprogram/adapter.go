@@ -7,6 +7,7 @@ import (
 )
 
 // ListGroups issues current list of exporter groups for groupcache groups.
+// See Workspaces in order to export groups from multiple workspaces.
 func ListGroups(ws *groupcache.Workspace) []groupcache_exporter.GroupStatistics {
 	groups := groupcache.GetGroups(ws)
 	var exportGroups []groupcache_exporter.GroupStatistics
@@ -16,6 +17,9 @@ func ListGroups(ws *groupcache.Workspace) []groupcache_exporter.GroupStatistics
 	return exportGroups
 }
 
+// Supported lists the metric families available from modernprogram groupcache.
+const Supported = groupcache_exporter.AllMetrics
+
 // exportGroup implements interface GroupStatistics to extract metrics from groupcache group.
 type exportGroup struct {
 	group *groupcache.Group
@@ -59,6 +63,11 @@ func getCacheStats(cacheStats groupcache.CacheStats) groupcache_exporter.CacheTy
 	}
 }
 
+// Supported returns the metric families filled by Collect.
+func (g *exportGroup) Supported() groupcache_exporter.Metrics {
+	return Supported
+}
+
 // Name returns the group's name
 func (g *exportGroup) Name() string {
 	return g.group.Name()
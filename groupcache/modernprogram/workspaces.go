@@ -0,0 +1,72 @@
+package modernprogram
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/modernprogram/groupcache/v2"
+	"github.com/udhos/groupcache_exporter"
+)
+
+// WorkspaceLabel is the label name that identifies the workspace of a group.
+// Add it to groupcache_exporter.Options.GroupLabels when exporting Workspaces,
+// otherwise groups sharing a name across workspaces fail collection.
+const WorkspaceLabel = "workspace"
+
+// Workspaces holds a set of named workspaces.
+// Workspaces may be added or removed at any time,
+// changes are picked up by the next call to ListGroups.
+type Workspaces struct {
+	mutex      sync.Mutex
+	workspaces map[string]*groupcache.Workspace
+}
+
+// NewWorkspaces creates an empty set of named workspaces.
+func NewWorkspaces() *Workspaces {
+	return &Workspaces{workspaces: map[string]*groupcache.Workspace{}}
+}
+
+// Add adds a named workspace, replacing any workspace with the same name.
+func (w *Workspaces) Add(name string, ws *groupcache.Workspace) {
+	w.mutex.Lock()
+	w.workspaces[name] = ws
+	w.mutex.Unlock()
+}
+
+// Remove removes a named workspace.
+func (w *Workspaces) Remove(name string) {
+	w.mutex.Lock()
+	delete(w.workspaces, name)
+	w.mutex.Unlock()
+}
+
+// ListGroups issues current list of exporter groups for groupcache groups
+// in all workspaces. Each group is labeled with its workspace name under WorkspaceLabel.
+func (w *Workspaces) ListGroups() []groupcache_exporter.GroupStatistics {
+	w.mutex.Lock()
+	workspaces := maps.Clone(w.workspaces)
+	w.mutex.Unlock()
+
+	var exportGroups []groupcache_exporter.GroupStatistics
+	for _, name := range slices.Sorted(maps.Keys(workspaces)) {
+		for _, g := range groupcache.GetGroups(workspaces[name]) {
+			exportGroups = append(exportGroups, &workspaceGroup{
+				exportGroup: exportGroup{group: g},
+				workspace:   name,
+			})
+		}
+	}
+	return exportGroups
+}
+
+// workspaceGroup is an exportGroup labeled with its workspace name.
+type workspaceGroup struct {
+	exportGroup
+	workspace string
+}
+
+// GroupLabels returns the workspace label.
+func (g *workspaceGroup) GroupLabels() map[string]string {
+	return map[string]string{WorkspaceLabel: g.workspace}
+}
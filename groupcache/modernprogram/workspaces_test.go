@@ -0,0 +1,71 @@
+package modernprogram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modernprogram/groupcache/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/udhos/groupcache_exporter"
+)
+
+// go test -count 1 -run '^TestWorkspaces$' ./...
+func TestWorkspaces(t *testing.T) {
+
+	workspaces := NewWorkspaces()
+
+	for _, name := range []string{"ws1", "ws2"} {
+		ws := groupcache.NewWorkspace()
+		groupcache.NewGroupWithWorkspace(groupcache.Options{
+			Workspace: ws,
+			Name:      "files", // same group name in both workspaces
+			Getter: groupcache.GetterFunc(
+				func(_ context.Context, _ string, _ groupcache.Sink, _ *groupcache.Info) error {
+					return nil
+				}),
+		})
+		workspaces.Add(name, ws)
+	}
+
+	unlabeled := groupcache_exporter.NewExporter(groupcache_exporter.Options{
+		ListGroups: workspaces.ListGroups,
+		Supported:  Supported,
+	})
+	unlabeledRegistry := prometheus.NewPedanticRegistry()
+	unlabeledRegistry.MustRegister(unlabeled)
+	if _, err := unlabeledRegistry.Gather(); err == nil || !strings.Contains(err.Error(), `add labels ["workspace"] to Options.GroupLabels`) {
+		t.Errorf("two workspaces without GroupLabels: expected duplicate group error, got: %v", err)
+	}
+
+	exporter := groupcache_exporter.NewExporter(groupcache_exporter.Options{
+		ListGroups:  workspaces.ListGroups,
+		Supported:   Supported,
+		GroupLabels: []string{WorkspaceLabel},
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(exporter)
+
+	expected := `
+# HELP groupcache_gets_total Count of cache gets (including from peers)
+# TYPE groupcache_gets_total counter
+groupcache_gets_total{group="files",workspace="ws1"} 0
+groupcache_gets_total{group="files",workspace="ws2"} 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "groupcache_gets_total"); err != nil {
+		t.Errorf("two workspaces: %v", err)
+	}
+
+	workspaces.Remove("ws1")
+
+	expected = `
+# HELP groupcache_gets_total Count of cache gets (including from peers)
+# TYPE groupcache_gets_total counter
+groupcache_gets_total{group="files",workspace="ws2"} 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "groupcache_gets_total"); err != nil {
+		t.Errorf("one workspace: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package groupcache_exporter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// groupNamePattern extracts extra labels from group names
+// using the named capture groups of Options.GroupNamePattern.
+type groupNamePattern struct {
+	re       *regexp.Regexp
+	labels   []string // capture group names, in order
+	indexes  []int    // submatch index for each label
+	fallback string
+}
+
+var labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// newGroupNamePattern compiles the pattern and checks its label names against
+// labels already in use: constant labels and variable labels.
+func newGroupNamePattern(pattern, fallback string, constLabels map[string]string,
+	variableLabels []string) (*groupNamePattern, error) {
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("group name pattern: %w", err)
+	}
+
+	p := &groupNamePattern{re: re, fallback: fallback}
+
+	used := slices.Clone(variableLabels)
+	used = append(used, "type", "window")
+
+	for i, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if !labelNameRegexp.MatchString(name) || strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("group name pattern: invalid label name: %q", name)
+		}
+		if _, found := constLabels[name]; found {
+			return nil, fmt.Errorf("group name pattern: label %q clashes with Options.Labels", name)
+		}
+		if slices.Contains(used, name) {
+			return nil, fmt.Errorf("group name pattern: duplicate label %q", name)
+		}
+		used = append(used, name)
+		p.labels = append(p.labels, name)
+		p.indexes = append(p.indexes, i)
+	}
+
+	if len(p.labels) == 0 {
+		return nil, errors.New("group name pattern: no named capture group")
+	}
+
+	return p, nil
+}
+
+// values returns the label values extracted from the group name,
+// or the fallback value for every label if the name does not match.
+func (p *groupNamePattern) values(name string) []string {
+	values := make([]string, len(p.labels))
+	m := p.re.FindStringSubmatch(name)
+	for i, index := range p.indexes {
+		if m == nil {
+			values[i] = p.fallback
+			continue
+		}
+		values[i] = m[index]
+	}
+	return values
+}
@@ -0,0 +1,68 @@
+package groupcache_exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// go test -count 1 -run '^TestGroupNamePattern$' ./...
+func TestGroupNamePattern(t *testing.T) {
+	g1 := &fakeGroup{name: "acme:users:v2"}
+	g1.stats.Group.CounterGets = 3
+	g2 := &fakeGroup{name: "legacy"}
+	g2.stats.Group.CounterGets = 4
+
+	e := NewExporter(Options{
+		ListGroups:        listGroups(g1, g2),
+		Labels:            map[string]string{"app": "test"},
+		GroupNamePattern:  `^(?P<tenant>[^:]+):(?P<dataset>[^:]+):(?P<version>[^:]+)$`,
+		GroupNameFallback: "unknown",
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(e)
+
+	expected := `
+# HELP groupcache_gets_total Count of cache gets (including from peers)
+# TYPE groupcache_gets_total counter
+groupcache_gets_total{app="test",dataset="users",group="acme:users:v2",tenant="acme",version="v2"} 3
+groupcache_gets_total{app="test",dataset="unknown",group="legacy",tenant="unknown",version="unknown"} 4
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"groupcache_gets_total"); err != nil {
+		t.Error(err)
+	}
+
+	if count := testutil.CollectAndCount(e, "groupcache_cache_items"); count != 4 {
+		t.Errorf("cache items: expected 4 series, got %d", count)
+	}
+}
+
+// go test -count 1 -run '^TestGroupNamePatternInvalid$' ./...
+func TestGroupNamePatternInvalid(t *testing.T) {
+	for _, c := range []struct {
+		pattern     string
+		groupLabels []string
+	}{
+		{pattern: `(?P<app>.*)`},   // clashes with Options.Labels
+		{pattern: `(?P<group>.*)`}, // clashes with group
+		{pattern: `(?P<type>.*)`},  // clashes with type
+		{pattern: `(?P<workspace>.*)`, groupLabels: []string{"workspace"}},
+		{pattern: `(?P<__x>.*)`}, // reserved
+		{pattern: `(.*)`},        // no named capture
+		{pattern: `(?P<a>.*`},    // malformed
+	} {
+		e := NewExporter(Options{
+			ListGroups:       listGroups(),
+			Labels:           map[string]string{"app": "test"},
+			GroupLabels:      c.groupLabels,
+			GroupNamePattern: c.pattern,
+		})
+		if err := prometheus.NewRegistry().Register(e); err == nil {
+			t.Errorf("pattern %q: expected registration error", c.pattern)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package jsonstats serves groupcache stats as JSON, for humans and scripts.
+//
+//	http.Handle("/groupcache/stats", jsonstats.NewHandler(jsonstats.Options{
+//		ListGroups: func() []groupcache_exporter.GroupStatistics { return modernprogram.ListGroups(workspace) },
+//	}))
+//
+// Query parameters:
+//
+//	group=name  only report the named group; may be repeated.
+//	delta=token also report the change since the previous request with the same token.
+//
+// See Document for the JSON schema.
+package jsonstats
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/udhos/groupcache_exporter"
+)
+
+// Options define parameters for Handler.
+type Options struct {
+	// ListGroups is usually the same function given to groupcache_exporter.Options.
+	ListGroups func() []groupcache_exporter.GroupStatistics
+
+	// Labels are reported at document level, like groupcache_exporter.Options.Labels.
+	Labels map[string]string
+
+	// MaxTokens caps the number of delta tokens tracked.
+	// The least recently used token is evicted beyond the cap.
+	// If undefined, defaults to 100.
+	MaxTokens int
+
+	// TokenTTL expires delta tokens not used for longer than TTL.
+	// If undefined, defaults to one hour.
+	TokenTTL time.Duration
+}
+
+// Handler serves groupcache stats as JSON.
+type Handler struct {
+	options Options
+	now     func() time.Time
+
+	mutex  sync.Mutex
+	tokens map[string]*token
+}
+
+type token struct {
+	tracker  *groupcache_exporter.DeltaTracker
+	lastUsed time.Time
+}
+
+// NewHandler creates Handler.
+func NewHandler(options Options) *Handler {
+	if options.MaxTokens < 1 {
+		options.MaxTokens = 100
+	}
+	if options.TokenTTL <= 0 {
+		options.TokenTTL = time.Hour
+	}
+	return &Handler{
+		options: options,
+		now:     time.Now,
+		tokens:  map[string]*token{},
+	}
+}
+
+// ServeHTTP serves the JSON document.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := query["group"]
+
+	var tracker *groupcache_exporter.DeltaTracker
+	if t := query.Get("delta"); t != "" {
+		tracker = h.tracker(t)
+	}
+
+	doc := h.Document(filter, tracker)
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		slog.Error("jsonstats encode", "error", err)
+	}
+}
+
+// Document builds the JSON document for groups named in filter, or all groups
+// if filter is empty. If tracker is not nil, deltas are included.
+func (h *Handler) Document(filter []string, tracker *groupcache_exporter.DeltaTracker) Document {
+	doc := Document{
+		Version:   Version,
+		Timestamp: h.now().UTC(),
+		Labels:    h.options.Labels,
+		Groups:    []Group{},
+	}
+
+	for _, group := range h.options.ListGroups() {
+		name := group.Name()
+		if len(filter) > 0 && !slices.Contains(filter, name) {
+			continue
+		}
+
+		stats := group.Collect()
+
+		g := Group{
+			Name:      name,
+			Supported: groupcache_exporter.SupportedBy(group).Names(),
+			Labels:    groupcache_exporter.LabelsOf(group),
+			Stats:     FromStats(stats),
+		}
+
+		if tracker != nil {
+			if delta, ok := tracker.Update(groupcache_exporter.GroupKey(group), stats); ok {
+				d := FromDelta(delta)
+				g.Delta = &d
+			}
+		}
+
+		doc.Groups = append(doc.Groups, g)
+	}
+
+	return doc
+}
+
+// tracker returns the delta tracker for the token, creating it if needed.
+func (h *Handler) tracker(name string) *groupcache_exporter.DeltaTracker {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	now := h.now()
+
+	for k, t := range h.tokens {
+		if now.Sub(t.lastUsed) > h.options.TokenTTL {
+			delete(h.tokens, k)
+		}
+	}
+
+	if t, found := h.tokens[name]; found {
+		t.lastUsed = now
+		return t.tracker
+	}
+
+	if len(h.tokens) >= h.options.MaxTokens {
+		var oldest string
+		for k, t := range h.tokens {
+			if oldest == "" || t.lastUsed.Before(h.tokens[oldest].lastUsed) {
+				oldest = k
+			}
+		}
+		delete(h.tokens, oldest)
+	}
+
+	t := &token{tracker: groupcache_exporter.NewDeltaTracker(), lastUsed: now}
+	h.tokens[name] = t
+	return t.tracker
+}
@@ -0,0 +1,82 @@
+package jsonstats
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/udhos/groupcache_exporter"
+)
+
+type fakeGroup struct {
+	name  string
+	stats groupcache_exporter.Stats
+}
+
+func (g *fakeGroup) Collect() groupcache_exporter.Stats { return g.stats }
+
+func (g *fakeGroup) Name() string { return g.name }
+
+func get(t *testing.T, h http.Handler, url string) Document {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+	var doc Document
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return doc
+}
+
+// go test -count 1 -run '^TestHandler$' ./...
+func TestHandler(t *testing.T) {
+
+	g1 := &fakeGroup{name: "group1"}
+	g2 := &fakeGroup{name: "group2"}
+
+	h := NewHandler(Options{
+		ListGroups: func() []groupcache_exporter.GroupStatistics {
+			return []groupcache_exporter.GroupStatistics{g1, g2}
+		},
+		Labels: map[string]string{"app": "test"},
+	})
+
+	g1.stats.Group.CounterGets = 10
+	g1.stats.Main.GaugeCacheBytes = 100
+
+	doc := get(t, h, "/?group=group1")
+	if doc.Version != Version || doc.Labels["app"] != "test" {
+		t.Errorf("unexpected document header: %+v", doc)
+	}
+	if len(doc.Groups) != 1 || doc.Groups[0].Name != "group1" {
+		t.Fatalf("filter: expected only group1, got %+v", doc.Groups)
+	}
+	if got := doc.Groups[0].Stats.ToStats(); got != g1.stats {
+		t.Errorf("round trip: expected %+v, got %+v", g1.stats, got)
+	}
+	if doc.Groups[0].Delta != nil {
+		t.Errorf("no delta expected without token")
+	}
+
+	if doc := get(t, h, "/"); len(doc.Groups) != 2 {
+		t.Errorf("expected 2 groups without filter, got %d", len(doc.Groups))
+	}
+
+	get(t, h, "/?delta=token1") // baseline for token1
+
+	g1.stats.Group.CounterGets = 25
+
+	doc = get(t, h, "/?delta=token1&group=group1")
+	if doc.Groups[0].Delta == nil || doc.Groups[0].Delta.Group.Gets != 15 {
+		t.Errorf("expected delta of 15 gets, got %+v", doc.Groups[0].Delta)
+	}
+
+	doc = get(t, h, "/?delta=token2&group=group1")
+	if doc.Groups[0].Delta != nil {
+		t.Errorf("new token should have no delta")
+	}
+}
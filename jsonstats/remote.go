@@ -0,0 +1,105 @@
+package jsonstats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+
+	"github.com/udhos/groupcache_exporter"
+)
+
+// Fetch retrieves the Document served by a remote Handler.
+// If client is nil, http.DefaultClient is used.
+func Fetch(ctx context.Context, client *http.Client, url string) (Document, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var doc Document
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return doc, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return doc, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return doc, fmt.Errorf("fetch %s: status %d: %s", url, resp.StatusCode, body)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return doc, fmt.Errorf("fetch %s: decode: %w", url, err)
+	}
+
+	if doc.Version != Version {
+		return doc, fmt.Errorf("fetch %s: unsupported version %d", url, doc.Version)
+	}
+
+	return doc, nil
+}
+
+// ListGroups turns the document back into GroupStatistics, reporting the
+// stats as of the document. Document labels, group labels and extraLabels
+// are merged, in this order, into labels provided by interface
+// groupcache_exporter.GroupLabels.
+// A group with an empty supported list is assumed to support AllMetrics.
+// Unknown supported metric names are logged.
+func (d Document) ListGroups(extraLabels map[string]string) []groupcache_exporter.GroupStatistics {
+	groups := make([]groupcache_exporter.GroupStatistics, 0, len(d.Groups))
+	for _, g := range d.Groups {
+		labels := maps.Clone(d.Labels)
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		maps.Copy(labels, g.Labels)
+		maps.Copy(labels, extraLabels)
+
+		// documents written by hand may omit supported
+		supported := groupcache_exporter.AllMetrics
+		if len(g.Supported) > 0 {
+			var unknown []string
+			supported, unknown = groupcache_exporter.ParseMetrics(g.Supported)
+			if len(unknown) > 0 {
+				slog.Warn("jsonstats: unknown supported metrics", "group", g.Name, "unknown", unknown)
+			}
+		}
+
+		groups = append(groups, &remoteGroup{
+			name:      g.Name,
+			labels:    labels,
+			supported: supported,
+			stats:     g.Stats.ToStats(),
+		})
+	}
+	return groups
+}
+
+// remoteGroup implements GroupStatistics for a group decoded from a Document.
+type remoteGroup struct {
+	name      string
+	labels    map[string]string
+	supported groupcache_exporter.Metrics
+	stats     groupcache_exporter.Stats
+}
+
+// Collect returns the stats decoded from the document.
+func (g *remoteGroup) Collect() groupcache_exporter.Stats { return g.stats }
+
+// Name returns the group's name.
+func (g *remoteGroup) Name() string { return g.name }
+
+// Supported returns the metric families reported by the remote application.
+func (g *remoteGroup) Supported() groupcache_exporter.Metrics { return g.supported }
+
+// GroupLabels returns the merged labels.
+func (g *remoteGroup) GroupLabels() map[string]string { return g.labels }
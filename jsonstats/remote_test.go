@@ -0,0 +1,70 @@
+package jsonstats
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/udhos/groupcache_exporter"
+)
+
+// go test -count 1 -run '^TestFetch$' ./...
+func TestFetch(t *testing.T) {
+	g := &fakeGroup{name: "group1"}
+	g.stats.Group.CounterGets = 3
+
+	h := NewHandler(Options{
+		ListGroups: func() []groupcache_exporter.GroupStatistics {
+			return []groupcache_exporter.GroupStatistics{g}
+		},
+		Labels: map[string]string{"app": "test"},
+	})
+
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	doc, err := Fetch(context.Background(), nil, s.URL)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	groups := doc.ListGroups(map[string]string{"instance": "app1"})
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %d", len(groups))
+	}
+	remote := groups[0]
+	if remote.Name() != "group1" || remote.Collect() != g.stats {
+		t.Errorf("unexpected group: %s %+v", remote.Name(), remote.Collect())
+	}
+	if got := groupcache_exporter.SupportedBy(remote); got != groupcache_exporter.AllMetrics {
+		t.Errorf("expected AllMetrics, got %b", got)
+	}
+	labels := remote.(groupcache_exporter.GroupLabels).GroupLabels()
+	if labels["app"] != "test" || labels["instance"] != "app1" {
+		t.Errorf("unexpected labels: %v", labels)
+	}
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	if _, err := Fetch(context.Background(), nil, notFound.URL); err == nil {
+		t.Errorf("expected error for status 404")
+	}
+}
+
+// go test -count 1 -run '^TestListGroupsSupported$' ./...
+func TestListGroupsSupported(t *testing.T) {
+	doc := Document{Version: Version, Groups: []Group{
+		{Name: "legacy"},
+		{Name: "partial", Supported: []string{"gets", "hits", "bogus"}},
+	}}
+
+	groups := doc.ListGroups(nil)
+
+	if got := groupcache_exporter.SupportedBy(groups[0]); got != groupcache_exporter.AllMetrics {
+		t.Errorf("missing supported: expected AllMetrics, got %b", got)
+	}
+	if got := groupcache_exporter.SupportedBy(groups[1]); got != groupcache_exporter.MetricGets|groupcache_exporter.MetricHits {
+		t.Errorf("partial supported: expected gets and hits, got %b", got)
+	}
+}
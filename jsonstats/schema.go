@@ -0,0 +1,194 @@
+package jsonstats
+
+import (
+	"time"
+
+	"github.com/udhos/groupcache_exporter"
+)
+
+// Version of the JSON document. It changes only on incompatible changes.
+const Version = 1
+
+// Document is the JSON document served by Handler.
+//
+//	{
+//	  "version": 1,
+//	  "timestamp": "2026-01-02T15:04:05Z",
+//	  "labels": {"app": "myapp"},
+//	  "groups": [
+//	    {
+//	      "name": "files",
+//	      "labels": {"workspace": "ws1"},
+//	      "supported": ["gets", "hits", ...],
+//	      "stats": {"group": {...}, "main": {...}, "hot": {...}},
+//	      "delta": {"elapsed_seconds": 10.0, "reset": false, "group": {...}, "main": {...}, "hot": {...}}
+//	    }
+//	  ]
+//	}
+//
+// Field delta is only present in delta mode, for groups seen by the
+// previous request with the same token.
+type Document struct {
+	Version   int               `json:"version"`
+	Timestamp time.Time         `json:"timestamp"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Groups    []Group           `json:"groups"`
+}
+
+// Group holds stats for a single group.
+type Group struct {
+	Name string `json:"name"`
+
+	// Labels holds values provided by groupcache_exporter.GroupLabels.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Supported lists the supported metric families, see groupcache_exporter.Metrics.Names.
+	Supported []string `json:"supported"`
+
+	Stats Stats  `json:"stats"`
+	Delta *Delta `json:"delta,omitempty"`
+}
+
+// Stats mirrors groupcache_exporter.Stats.
+type Stats struct {
+	Group GroupStats     `json:"group"`
+	Main  CacheTypeStats `json:"main"`
+	Hot   CacheTypeStats `json:"hot"`
+}
+
+// GroupStats mirrors groupcache_exporter.GroupStats.
+type GroupStats struct {
+	Gets                             int64   `json:"gets"`
+	Hits                             int64   `json:"hits"`
+	GetFromPeersLatencySlowestMillis float64 `json:"get_from_peers_latency_slowest_milliseconds"`
+	PeerLoads                        int64   `json:"peer_loads"`
+	PeerErrors                       int64   `json:"peer_errors"`
+	Loads                            int64   `json:"loads"`
+	LoadsDeduped                     int64   `json:"loads_deduped"`
+	LocalLoads                       int64   `json:"local_loads"`
+	LocalLoadErrs                    int64   `json:"local_load_errs"`
+	ServerRequests                   int64   `json:"server_requests"`
+	CrosstalkRefusals                int64   `json:"crosstalk_refusals"`
+}
+
+// CacheTypeStats mirrors groupcache_exporter.CacheTypeStats.
+type CacheTypeStats struct {
+	Items               int64 `json:"items"`
+	Bytes               int64 `json:"bytes"`
+	Gets                int64 `json:"gets"`
+	Hits                int64 `json:"hits"`
+	Evictions           int64 `json:"evictions"`
+	EvictionsNonExpired int64 `json:"evictions_nonexpired"`
+}
+
+// Delta mirrors groupcache_exporter.StatsDelta.
+// Counter deltas are never negative, since counter resets are
+// handled as restarts from zero. Gauge deltas may be negative.
+type Delta struct {
+	ElapsedSeconds float64        `json:"elapsed_seconds"`
+	Reset          bool           `json:"reset"`
+	Group          GroupStats     `json:"group"`
+	Main           CacheTypeStats `json:"main"`
+	Hot            CacheTypeStats `json:"hot"`
+}
+
+// FromStats converts groupcache_exporter.Stats.
+func FromStats(s groupcache_exporter.Stats) Stats {
+	g := s.Group
+	return Stats{
+		Group: GroupStats{
+			Gets:                             g.CounterGets,
+			Hits:                             g.CounterHits,
+			GetFromPeersLatencySlowestMillis: g.GaugeGetFromPeersLatencyLower,
+			PeerLoads:                        g.CounterPeerLoads,
+			PeerErrors:                       g.CounterPeerErrors,
+			Loads:                            g.CounterLoads,
+			LoadsDeduped:                     g.CounterLoadsDeduped,
+			LocalLoads:                       g.CounterLocalLoads,
+			LocalLoadErrs:                    g.CounterLocalLoadsErrs,
+			ServerRequests:                   g.CounterServerRequests,
+			CrosstalkRefusals:                g.CounterCrosstalkRefusals,
+		},
+		Main: fromCacheTypeStats(s.Main),
+		Hot:  fromCacheTypeStats(s.Hot),
+	}
+}
+
+func fromCacheTypeStats(c groupcache_exporter.CacheTypeStats) CacheTypeStats {
+	return CacheTypeStats{
+		Items:               c.GaugeCacheItems,
+		Bytes:               c.GaugeCacheBytes,
+		Gets:                c.CounterCacheGets,
+		Hits:                c.CounterCacheHits,
+		Evictions:           c.CounterCacheEvictions,
+		EvictionsNonExpired: c.CounterCacheEvictionsNonExpired,
+	}
+}
+
+// ToStats converts back into groupcache_exporter.Stats.
+func (s Stats) ToStats() groupcache_exporter.Stats {
+	g := s.Group
+	return groupcache_exporter.Stats{
+		Group: groupcache_exporter.GroupStats{
+			CounterGets:                   g.Gets,
+			CounterHits:                   g.Hits,
+			GaugeGetFromPeersLatencyLower: g.GetFromPeersLatencySlowestMillis,
+			CounterPeerLoads:              g.PeerLoads,
+			CounterPeerErrors:             g.PeerErrors,
+			CounterLoads:                  g.Loads,
+			CounterLoadsDeduped:           g.LoadsDeduped,
+			CounterLocalLoads:             g.LocalLoads,
+			CounterLocalLoadsErrs:         g.LocalLoadErrs,
+			CounterServerRequests:         g.ServerRequests,
+			CounterCrosstalkRefusals:      g.CrosstalkRefusals,
+		},
+		Main: s.Main.toCacheTypeStats(),
+		Hot:  s.Hot.toCacheTypeStats(),
+	}
+}
+
+func (c CacheTypeStats) toCacheTypeStats() groupcache_exporter.CacheTypeStats {
+	return groupcache_exporter.CacheTypeStats{
+		GaugeCacheItems:                 c.Items,
+		GaugeCacheBytes:                 c.Bytes,
+		CounterCacheGets:                c.Gets,
+		CounterCacheHits:                c.Hits,
+		CounterCacheEvictions:           c.Evictions,
+		CounterCacheEvictionsNonExpired: c.EvictionsNonExpired,
+	}
+}
+
+// FromDelta converts groupcache_exporter.StatsDelta.
+func FromDelta(d groupcache_exporter.StatsDelta) Delta {
+	g := d.Group
+	return Delta{
+		ElapsedSeconds: d.Elapsed.Seconds(),
+		Reset:          d.Reset,
+		Group: GroupStats{
+			Gets:                             g.Gets,
+			Hits:                             g.Hits,
+			GetFromPeersLatencySlowestMillis: g.GetFromPeersLatencyLower,
+			PeerLoads:                        g.PeerLoads,
+			PeerErrors:                       g.PeerErrors,
+			Loads:                            g.Loads,
+			LoadsDeduped:                     g.LoadsDeduped,
+			LocalLoads:                       g.LocalLoads,
+			LocalLoadErrs:                    g.LocalLoadsErrs,
+			ServerRequests:                   g.ServerRequests,
+			CrosstalkRefusals:                g.CrosstalkRefusals,
+		},
+		Main: fromCacheTypeDelta(d.Main),
+		Hot:  fromCacheTypeDelta(d.Hot),
+	}
+}
+
+func fromCacheTypeDelta(c groupcache_exporter.CacheTypeDelta) CacheTypeStats {
+	return CacheTypeStats{
+		Items:               c.Items,
+		Bytes:               c.Bytes,
+		Gets:                c.Gets,
+		Hits:                c.Hits,
+		Evictions:           c.Evictions,
+		EvictionsNonExpired: c.EvictionsNonExpired,
+	}
+}
@@ -0,0 +1,126 @@
+package groupcache_exporter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyTracker turns the all-time slowest peer latency reported by
+// groupcache into maximum values over sliding windows.
+//
+// Upstream only keeps the slowest latency ever seen, so the tracker
+// records a sample whenever the raw value grows between scrapes.
+// A raw value lower than the previous one means upstream was reset,
+// and the new value is recorded as a fresh sample.
+type latencyTracker struct {
+	windows   []time.Duration
+	maxWindow time.Duration
+	now       func() time.Time
+
+	mutex  sync.Mutex
+	groups map[string]*latencyGroup
+}
+
+type latencyGroup struct {
+	raw      float64
+	lastSeen time.Time
+	samples  []latencySample
+}
+
+type latencySample struct {
+	when  time.Time
+	value float64
+}
+
+func newLatencyTracker(windows []time.Duration) *latencyTracker {
+	return &latencyTracker{
+		windows:   windows,
+		maxWindow: slices.Max(windows),
+		now:       time.Now,
+		groups:    map[string]*latencyGroup{},
+	}
+}
+
+// observe records the raw upstream value for the group identified by key and returns
+// the maximum value for each window, in the same order as windows.
+func (t *latencyTracker) observe(key string, raw float64) []float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.now()
+
+	g, found := t.groups[key]
+	switch {
+	case !found:
+		g = &latencyGroup{}
+		t.groups[key] = g
+		if raw > 0 {
+			g.samples = append(g.samples, latencySample{when: now, value: raw})
+		}
+	case raw != g.raw && raw > 0:
+		// either upstream recorded a slower call or it was reset
+		g.samples = append(g.samples, latencySample{when: now, value: raw})
+	}
+	g.raw = raw
+	g.lastSeen = now
+
+	// drop samples older than the largest window
+	cut := 0
+	for cut < len(g.samples) && now.Sub(g.samples[cut].when) > t.maxWindow {
+		cut++
+	}
+	g.samples = g.samples[cut:]
+
+	result := make([]float64, len(t.windows))
+	for i, w := range t.windows {
+		for _, s := range g.samples {
+			if now.Sub(s.when) <= w {
+				result[i] = max(result[i], s.value)
+			}
+		}
+	}
+
+	return result
+}
+
+// expire forgets groups not seen for longer than the largest window.
+// It is called once per scrape, rather than by observe, since it visits every group.
+func (t *latencyTracker) expire() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := t.now()
+	for name, g := range t.groups {
+		if now.Sub(g.lastSeen) > t.maxWindow {
+			delete(t.groups, name)
+		}
+	}
+}
+
+// validateWindows rejects windows that are not positive or are repeated,
+// since each window is exported as a distinct label value.
+func validateWindows(option string, windows []time.Duration) error {
+	for i, w := range windows {
+		if w <= 0 {
+			return fmt.Errorf("%s: window must be positive: %v", option, w)
+		}
+		if slices.Contains(windows[:i], w) {
+			return fmt.Errorf("%s: duplicate window: %v", option, w)
+		}
+	}
+	return nil
+}
+
+// windowLabel formats the window duration as a short label value: 1m, 5m, 30s, 1h30m.
+func windowLabel(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package groupcache_exporter
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// go test -count 1 -run '^TestLatencyTracker$' ./...
+func TestLatencyTracker(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	tracker := newLatencyTracker([]time.Duration{time.Minute, 5 * time.Minute})
+	tracker.now = clock.now
+
+	steps := []struct {
+		advance  time.Duration
+		raw      float64
+		expected []float64
+	}{
+		{0, 0, []float64{0, 0}},
+		{30 * time.Second, 100, []float64{100, 100}}, // slow call
+		{30 * time.Second, 100, []float64{100, 100}}, // unchanged, still within 1m
+		{40 * time.Second, 100, []float64{0, 100}},   // out of 1m window
+		{5 * time.Minute, 100, []float64{0, 0}},      // out of 5m window
+		{30 * time.Second, 0, []float64{0, 0}},       // upstream reset
+		{30 * time.Second, 40, []float64{40, 40}},    // new call after reset
+		{30 * time.Second, 30, []float64{40, 40}},    // reset again, then faster call
+		{40 * time.Second, 30, []float64{30, 40}},    // first sample left 1m window
+	}
+
+	for i, s := range steps {
+		clock.advance(s.advance)
+		got := tracker.observe("group1", s.raw)
+		if !slices.Equal(got, s.expected) {
+			t.Errorf("step %d: raw=%v expected=%v got=%v", i, s.raw, s.expected, got)
+		}
+	}
+}
+
+// go test -count 1 -run '^TestWindowLabel$' ./...
+func TestWindowLabel(t *testing.T) {
+	table := map[time.Duration]string{
+		30 * time.Second:           "30s",
+		time.Minute:                "1m",
+		5 * time.Minute:            "5m",
+		90 * time.Second:           "1m30s",
+		time.Hour:                  "1h",
+		time.Hour + 30*time.Minute: "1h30m",
+	}
+	for d, expected := range table {
+		if got := windowLabel(d); got != expected {
+			t.Errorf("duration %v: expected %s got %s", d, expected, got)
+		}
+	}
+}
+
+// go test -count 1 -run '^TestLatencyWindowsInvalid$' ./...
+func TestLatencyWindowsInvalid(t *testing.T) {
+	for _, windows := range [][]time.Duration{
+		{time.Minute, 60 * time.Second},
+		{0},
+		{-time.Minute},
+	} {
+		e := NewExporter(Options{ListGroups: listGroups(), LatencyWindows: windows})
+		if err := prometheus.NewRegistry().Register(e); err == nil {
+			t.Errorf("windows %v: expected registration error", windows)
+		}
+	}
+}
+
+// go test -count 1 -run '^TestTrackersExpire$' ./...
+func TestTrackersExpire(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	windows := []time.Duration{time.Minute, 5 * time.Minute}
+
+	latency := newLatencyTracker(windows)
+	latency.now = clock.now
+	ratios := newRatioTracker(windows)
+	ratios.now = clock.now
+
+	latency.observe("a", 10)
+	ratios.observe("a", 1, 2)
+	clock.advance(4 * time.Minute)
+	latency.observe("b", 10)
+	ratios.observe("b", 1, 2)
+	clock.advance(2 * time.Minute)
+
+	latency.expire()
+	ratios.expire()
+
+	if _, found := latency.groups["a"]; found || len(latency.groups) != 1 {
+		t.Errorf("latency: expected only b, got %d groups", len(latency.groups))
+	}
+	if _, found := ratios.series["a"]; found || len(ratios.series) != 1 {
+		t.Errorf("ratio: expected only b, got %d series", len(ratios.series))
+	}
+}
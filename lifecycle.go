@@ -0,0 +1,126 @@
+package groupcache_exporter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// lifecycle remembers exported groups in order to report removed groups
+// as down during a grace period, instead of just dropping their series.
+type lifecycle struct {
+	grace time.Duration
+	now   func() time.Time
+
+	up        *prometheus.Desc
+	firstSeen *prometheus.Desc
+
+	mutex  sync.Mutex
+	groups map[string]*groupState
+}
+
+type groupState struct {
+	labelValues []string
+	firstSeen   time.Time
+	removed     time.Time // zero while listed
+}
+
+func newLifecycle(grace time.Duration, namespace, subsystem string, labels map[string]string,
+	groupLabels []string, builtin *builtinNames) *lifecycle {
+	return &lifecycle{
+		grace: grace,
+		now:   time.Now,
+		up: prometheus.NewDesc(
+			builtin.fqName(namespace, subsystem, "group_up"),
+			"Whether the group is currently listed (1) or was recently removed (0)",
+			groupLabels,
+			labels,
+		),
+		firstSeen: prometheus.NewDesc(
+			builtin.fqName(namespace, subsystem, "group_first_seen_timestamp_seconds"),
+			"Unix time when the group was first seen",
+			groupLabels,
+			labels,
+		),
+		groups: map[string]*groupState{},
+	}
+}
+
+func (l *lifecycle) describe(ch chan<- *prometheus.Desc) {
+	ch <- l.up
+	ch <- l.firstSeen
+}
+
+// observe records the label values of the groups currently listed.
+// Groups no longer listed are marked as removed, and forgotten after the grace period.
+func (l *lifecycle) observe(listed [][]string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+
+	present := make(map[string]struct{}, len(listed))
+	for _, labelValues := range listed {
+		key := seriesKey(labelValues)
+		present[key] = struct{}{}
+		g, found := l.groups[key]
+		if !found {
+			l.groups[key] = &groupState{labelValues: labelValues, firstSeen: now}
+			continue
+		}
+		g.removed = time.Time{}
+	}
+
+	for key, g := range l.groups {
+		if _, found := present[key]; found {
+			continue
+		}
+		if g.removed.IsZero() {
+			g.removed = now
+		}
+		if now.Sub(g.removed) >= l.grace {
+			delete(l.groups, key)
+		}
+	}
+}
+
+func (l *lifecycle) collect(ch chan<- prometheus.Metric, debug bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, g := range l.groups {
+		var up float64
+		if g.removed.IsZero() {
+			up = 1
+		}
+		ch <- metric(debug, "group_up", l.up, prometheus.GaugeValue, up, g.labelValues...)
+		ch <- metric(debug, "group_first_seen_timestamp_seconds", l.firstSeen, prometheus.GaugeValue,
+			float64(g.firstSeen.UnixNano())/1e9, g.labelValues...)
+	}
+}
+
+// observeGroups records the groups in the lifecycle.
+// Groups failing to provide valid label values are skipped,
+// since they are reported as collection failures.
+func (e *Exporter) observeGroups(groups []GroupStatistics) {
+	listed := make([][]string, 0, len(groups))
+	for _, g := range groups {
+		if labelValues, ok := e.safeLabelValues(g); ok {
+			listed = append(listed, labelValues)
+		}
+	}
+	e.lifecycle.observe(listed)
+}
+
+// safeLabelValues returns the label values for the group,
+// recovering from a panic in the group.
+func (e *Exporter) safeLabelValues(group GroupStatistics) (labelValues []string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+		}
+	}()
+	labelValues = e.labelValues(group)
+	return labelValues, validLabelValues(labelValues) == nil
+}
@@ -0,0 +1,97 @@
+package groupcache_exporter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// go test -count 1 -run '^TestLifecycle$' ./...
+func TestLifecycle(t *testing.T) {
+	start := time.Unix(1000, 0)
+	clock := &fakeClock{t: start}
+
+	g1 := &fakeGroup{name: "group1"}
+	g2 := &fakeGroup{name: "group2"}
+	groups := []GroupStatistics{g1, g2}
+
+	e := NewExporter(Options{
+		ListGroups:        func() []GroupStatistics { return groups },
+		Lifecycle:         true,
+		RemovedGroupGrace: time.Minute,
+	})
+	e.lifecycle.now = clock.now
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(e)
+
+	check := func(step string, up map[string]int, firstSeen map[string]int) {
+		t.Helper()
+		var b strings.Builder
+		b.WriteString("# HELP groupcache_group_up Whether the group is currently listed (1) or was recently removed (0)\n")
+		b.WriteString("# TYPE groupcache_group_up gauge\n")
+		for _, name := range []string{"group1", "group2"} {
+			if v, found := up[name]; found {
+				fmt.Fprintf(&b, "groupcache_group_up{group=%q} %d\n", name, v)
+			}
+		}
+		b.WriteString("# HELP groupcache_group_first_seen_timestamp_seconds Unix time when the group was first seen\n")
+		b.WriteString("# TYPE groupcache_group_first_seen_timestamp_seconds gauge\n")
+		for _, name := range []string{"group1", "group2"} {
+			if v, found := firstSeen[name]; found {
+				fmt.Fprintf(&b, "groupcache_group_first_seen_timestamp_seconds{group=%q} %d\n", name, v)
+			}
+		}
+		if err := testutil.GatherAndCompare(registry, strings.NewReader(b.String()),
+			"groupcache_group_up", "groupcache_group_first_seen_timestamp_seconds"); err != nil {
+			t.Errorf("%s: %v", step, err)
+		}
+	}
+
+	check("initial", map[string]int{"group1": 1, "group2": 1}, map[string]int{"group1": 1000, "group2": 1000})
+
+	clock.advance(30 * time.Second)
+	groups = []GroupStatistics{g1}
+	check("removed", map[string]int{"group1": 1, "group2": 0}, map[string]int{"group1": 1000, "group2": 1000})
+
+	clock.advance(time.Minute)
+	check("grace expired", map[string]int{"group1": 1}, map[string]int{"group1": 1000})
+
+	clock.advance(30 * time.Second)
+	groups = []GroupStatistics{g1, g2}
+	check("recreated", map[string]int{"group1": 1, "group2": 1}, map[string]int{"group1": 1000, "group2": 1120})
+}
+
+// go test -count 1 -run '^TestLifecycleFolded$' ./...
+func TestLifecycleFolded(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+
+	e := NewExporter(Options{
+		ListGroups:        listGroups(&fakeGroup{name: "group1"}, &fakeGroup{name: "group2"}),
+		Lifecycle:         true,
+		RemovedGroupGrace: time.Minute,
+		MaxGroups:         1,
+	})
+	e.lifecycle.now = clock.now
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(e)
+
+	expected := `
+# HELP groupcache_group_up Whether the group is currently listed (1) or was recently removed (0)
+# TYPE groupcache_group_up gauge
+groupcache_group_up{group="group1"} 1
+groupcache_group_up{group="group2"} 1
+`
+	for _, step := range []string{"initial", "after grace"} {
+		if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+			"groupcache_group_up"); err != nil {
+			t.Errorf("%s: %v", step, err)
+		}
+		clock.advance(2 * time.Minute)
+	}
+}
@@ -0,0 +1,196 @@
+// Package otelexporter exports groupcache metrics through the OpenTelemetry metrics API,
+// as an alternative to the Prometheus collector groupcache_exporter.Exporter.
+//
+//	registration, err := otelexporter.Register(otel.Meter("groupcache"), otelexporter.Options{
+//		ListGroups: func() []groupcache_exporter.GroupStatistics { return modernprogram.ListGroups(workspace) },
+//		Supported:  modernprogram.Supported,
+//	})
+package otelexporter
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/udhos/groupcache_exporter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Attribute keys.
+const (
+	AttributeGroup     = "groupcache.group"
+	AttributeCacheType = "groupcache.cache.type"
+)
+
+// Options define parameters for Register.
+type Options struct {
+	// ListGroups is usually the same function given to groupcache_exporter.Options.
+	ListGroups func() []groupcache_exporter.GroupStatistics
+
+	// Attributes are attached to every measurement, like groupcache_exporter.Options.Labels.
+	Attributes []attribute.KeyValue
+
+	// Supported restricts the exported metrics. If undefined, defaults to AllMetrics.
+	Supported groupcache_exporter.Metrics
+
+	// GroupLabels lists extra attributes taken from groups implementing
+	// groupcache_exporter.GroupLabels, like groupcache_exporter.Options.GroupLabels.
+	GroupLabels []string
+}
+
+// groupCounter maps a GroupStats counter to an instrument.
+type groupCounter struct {
+	metric      groupcache_exporter.Metrics
+	name        string
+	unit        string
+	description string
+	value       func(groupcache_exporter.GroupStats) int64
+	instrument  metric.Int64ObservableCounter
+}
+
+// cacheInstrument maps a CacheTypeStats field to an instrument.
+type cacheInstrument struct {
+	metric      groupcache_exporter.Metrics
+	name        string
+	unit        string
+	description string
+	gauge       bool // usage-like value, exported as UpDownCounter
+	value       func(groupcache_exporter.CacheTypeStats) int64
+	instrument  metric.Int64Observable
+}
+
+// Register creates observable instruments on the meter and registers
+// a callback that collects stats from every group on each collection.
+// Call Unregister on the result to stop collection.
+func Register(meter metric.Meter, options Options) (metric.Registration, error) {
+
+	supported := options.Supported
+	if supported == 0 {
+		supported = groupcache_exporter.AllMetrics
+	}
+
+	type G = groupcache_exporter.GroupStats
+	type C = groupcache_exporter.CacheTypeStats
+
+	counters := []*groupCounter{
+		{groupcache_exporter.MetricGets, "groupcache.gets", "{get}", "Count of cache gets (including from peers)", func(s G) int64 { return s.CounterGets }, nil},
+		{groupcache_exporter.MetricHits, "groupcache.hits", "{hit}", "Count of cache hits (from either main or hot cache)", func(s G) int64 { return s.CounterHits }, nil},
+		{groupcache_exporter.MetricPeerLoads, "groupcache.peer.loads", "{load}", "Count of non-error loads or cache hits from peers", func(s G) int64 { return s.CounterPeerLoads }, nil},
+		{groupcache_exporter.MetricPeerErrors, "groupcache.peer.errors", "{error}", "Count of errors from peers", func(s G) int64 { return s.CounterPeerErrors }, nil},
+		{groupcache_exporter.MetricLoads, "groupcache.loads", "{load}", "Count of (gets - hits)", func(s G) int64 { return s.CounterLoads }, nil},
+		{groupcache_exporter.MetricLoadsDeduped, "groupcache.loads.deduped", "{load}", "Count of loads after singleflight", func(s G) int64 { return s.CounterLoadsDeduped }, nil},
+		{groupcache_exporter.MetricLocalLoads, "groupcache.local.loads", "{load}", "Count of loads from local cache", func(s G) int64 { return s.CounterLocalLoads }, nil},
+		{groupcache_exporter.MetricLocalLoadsErrs, "groupcache.local.load.errors", "{error}", "Count of loads from local cache that failed", func(s G) int64 { return s.CounterLocalLoadsErrs }, nil},
+		{groupcache_exporter.MetricServerRequests, "groupcache.server.requests", "{request}", "Count of gets that came over the network from peers", func(s G) int64 { return s.CounterServerRequests }, nil},
+		{groupcache_exporter.MetricCrosstalkRefusals, "groupcache.crosstalk.refusals", "{refusal}", "Count of refusals for additional crosstalks", func(s G) int64 { return s.CounterCrosstalkRefusals }, nil},
+	}
+
+	caches := []*cacheInstrument{
+		{groupcache_exporter.MetricCacheItems, "groupcache.cache.items", "{item}", "Current items in use", true, func(s C) int64 { return s.GaugeCacheItems }, nil},
+		{groupcache_exporter.MetricCacheBytes, "groupcache.cache.usage", "By", "Current bytes in use", true, func(s C) int64 { return s.GaugeCacheBytes }, nil},
+		{groupcache_exporter.MetricCacheGets, "groupcache.cache.gets", "{get}", "Count of cache gets", false, func(s C) int64 { return s.CounterCacheGets }, nil},
+		{groupcache_exporter.MetricCacheHits, "groupcache.cache.hits", "{hit}", "Count of cache hits", false, func(s C) int64 { return s.CounterCacheHits }, nil},
+		{groupcache_exporter.MetricCacheEvictions, "groupcache.cache.evictions", "{eviction}", "Count of cache evictions", false, func(s C) int64 { return s.CounterCacheEvictions }, nil},
+		{groupcache_exporter.MetricCacheEvictionsNonExpired, "groupcache.cache.evictions.nonexpired", "{eviction}", "Count of cache evictions for non-expired keys due to memory full", false, func(s C) int64 { return s.CounterCacheEvictionsNonExpired }, nil},
+	}
+
+	var instruments []metric.Observable
+	var errs []error
+
+	for _, c := range counters {
+		if !supported.Has(c.metric) {
+			continue
+		}
+		inst, err := meter.Int64ObservableCounter(c.name, metric.WithUnit(c.unit), metric.WithDescription(c.description))
+		errs = append(errs, err)
+		c.instrument = inst
+		instruments = append(instruments, inst)
+	}
+
+	var latency metric.Float64ObservableGauge
+	if supported.Has(groupcache_exporter.MetricGetFromPeersLatencyLower) {
+		inst, err := meter.Float64ObservableGauge("groupcache.peer.latency.slowest", metric.WithUnit("ms"),
+			metric.WithDescription("Slowest duration to request value from peers"))
+		errs = append(errs, err)
+		latency = inst
+		instruments = append(instruments, inst)
+	}
+
+	for _, c := range caches {
+		if !supported.Has(c.metric) {
+			continue
+		}
+		var inst metric.Int64Observable
+		var err error
+		if c.gauge {
+			inst, err = meter.Int64ObservableUpDownCounter(c.name, metric.WithUnit(c.unit), metric.WithDescription(c.description))
+		} else {
+			inst, err = meter.Int64ObservableCounter(c.name, metric.WithUnit(c.unit), metric.WithDescription(c.description))
+		}
+		errs = append(errs, err)
+		c.instrument = inst
+		instruments = append(instruments, inst)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	constant := options.Attributes
+
+	callback := func(_ context.Context, o metric.Observer) error {
+		for _, group := range options.ListGroups() {
+			stats := group.Collect()
+			groupSupported := supported & groupcache_exporter.SupportedBy(group)
+
+			attrs := append(slices.Clone(constant), attribute.String(AttributeGroup, group.Name()))
+			attrs = append(attrs, groupAttributes(group, options.GroupLabels)...)
+			groupSet := metric.WithAttributeSet(attribute.NewSet(attrs...))
+
+			for _, c := range counters {
+				if c.instrument != nil && groupSupported.Has(c.metric) {
+					o.ObserveInt64(c.instrument, c.value(stats.Group), groupSet)
+				}
+			}
+
+			if latency != nil && groupSupported.Has(groupcache_exporter.MetricGetFromPeersLatencyLower) {
+				o.ObserveFloat64(latency, stats.Group.GaugeGetFromPeersLatencyLower, groupSet)
+			}
+
+			for _, t := range []struct {
+				cacheType string
+				stats     groupcache_exporter.CacheTypeStats
+			}{
+				{"main", stats.Main},
+				{"hot", stats.Hot},
+			} {
+				typeSet := metric.WithAttributeSet(attribute.NewSet(
+					append(slices.Clone(attrs), attribute.String(AttributeCacheType, t.cacheType))...))
+				for _, c := range caches {
+					if c.instrument != nil && groupSupported.Has(c.metric) {
+						o.ObserveInt64(c.instrument, c.value(t.stats), typeSet)
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	return meter.RegisterCallback(callback, instruments...)
+}
+
+func groupAttributes(group groupcache_exporter.GroupStatistics, names []string) []attribute.KeyValue {
+	if len(names) == 0 {
+		return nil
+	}
+	var values map[string]string
+	if gl, ok := group.(groupcache_exporter.GroupLabels); ok {
+		values = gl.GroupLabels()
+	}
+	attrs := make([]attribute.KeyValue, 0, len(names))
+	for _, name := range names {
+		attrs = append(attrs, attribute.String(name, values[name]))
+	}
+	return attrs
+}
@@ -0,0 +1,84 @@
+package otelexporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/udhos/groupcache_exporter"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+type fakeGroup struct {
+	stats groupcache_exporter.Stats
+}
+
+func (g *fakeGroup) Collect() groupcache_exporter.Stats { return g.stats }
+
+func (g *fakeGroup) Name() string { return "group1" }
+
+func (g *fakeGroup) Supported() groupcache_exporter.Metrics {
+	return groupcache_exporter.AllMetrics &^ groupcache_exporter.MetricCrosstalkRefusals
+}
+
+// go test -count 1 -run '^TestRegister$' ./...
+func TestRegister(t *testing.T) {
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	g := &fakeGroup{
+		stats: groupcache_exporter.Stats{
+			Group: groupcache_exporter.GroupStats{CounterGets: 7},
+			Main:  groupcache_exporter.CacheTypeStats{GaugeCacheBytes: 100},
+		},
+	}
+
+	registration, err := Register(provider.Meter("test"), Options{
+		ListGroups: func() []groupcache_exporter.GroupStatistics { return []groupcache_exporter.GroupStatistics{g} },
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	defer registration.Unregister()
+
+	var rm metricdata.ResourceMetrics
+	if errCollect := reader.Collect(context.TODO(), &rm); errCollect != nil {
+		t.Fatalf("collect: %v", errCollect)
+	}
+
+	found := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			found[m.Name] = m
+		}
+	}
+
+	if _, ok := found["groupcache.crosstalk.refusals"]; ok {
+		t.Errorf("unsupported metric should not be observed")
+	}
+
+	gets, ok := found["groupcache.gets"]
+	if !ok {
+		t.Fatalf("missing groupcache.gets")
+	}
+	sum := gets.Data.(metricdata.Sum[int64])
+	if !sum.IsMonotonic || len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 7 {
+		t.Errorf("unexpected gets: %+v", sum)
+	}
+	if v, _ := sum.DataPoints[0].Attributes.Value(AttributeGroup); v.AsString() != "group1" {
+		t.Errorf("missing group attribute: %v", sum.DataPoints[0].Attributes)
+	}
+
+	usage, ok := found["groupcache.cache.usage"]
+	if !ok {
+		t.Fatalf("missing groupcache.cache.usage")
+	}
+	if usage.Unit != "By" {
+		t.Errorf("expected unit By, got %s", usage.Unit)
+	}
+	usageSum := usage.Data.(metricdata.Sum[int64])
+	if usageSum.IsMonotonic || len(usageSum.DataPoints) != 2 {
+		t.Errorf("expected non-monotonic usage for main and hot: %+v", usageSum)
+	}
+}
@@ -0,0 +1,163 @@
+package groupcache_exporter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OtherGroups is the group label value for groups beyond Options.MaxGroups.
+const OtherGroups = "__other__"
+
+// groupLimiter selects which groups are exported individually.
+// A group admitted individually stays so while it is listed,
+// hence the selection is stable between scrapes.
+type groupLimiter struct {
+	max    int
+	folded prometheus.Gauge
+
+	mutex    sync.Mutex
+	admitted map[string]struct{}
+}
+
+func newGroupLimiter(max int, namespace string, labels map[string]string, builtin *builtinNames) *groupLimiter {
+	return &groupLimiter{
+		max: max,
+		folded: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        builtin.fqName(namespace, "groupcache_exporter", "folded_groups"),
+			Help:        "Number of groups beyond the limit folded into group " + OtherGroups,
+			ConstLabels: labels,
+		}),
+		admitted: map[string]struct{}{},
+	}
+}
+
+// admit reports, for each group key, whether the group is exported individually.
+// Groups no longer listed release their slots.
+func (l *groupLimiter) admit(keys []string) []bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	listed := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		listed[k] = struct{}{}
+	}
+	for k := range l.admitted {
+		if _, found := listed[k]; !found {
+			delete(l.admitted, k)
+		}
+	}
+
+	result := make([]bool, len(keys))
+	for i, k := range keys {
+		if _, found := l.admitted[k]; !found && len(l.admitted) < l.max {
+			l.admitted[k] = struct{}{}
+		}
+		_, result[i] = l.admitted[k]
+	}
+	return result
+}
+
+// limitGroups folds groups beyond Options.MaxGroups into a single group.
+func (e *Exporter) limitGroups(groups []GroupStatistics) []GroupStatistics {
+	if e.limiter == nil {
+		return groups
+	}
+
+	var result, valid []GroupStatistics
+	var keys []string
+	for _, g := range groups {
+		labelValues, ok := e.safeLabelValues(g)
+		if !ok {
+			// exported individually, so collectFromGroup reports the failure
+			result = append(result, g)
+			continue
+		}
+		valid = append(valid, g)
+		keys = append(keys, seriesKey(labelValues))
+	}
+
+	other := &otherGroup{failed: e.memberFailure}
+	for i, individual := range e.limiter.admit(keys) {
+		if individual {
+			result = append(result, valid[i])
+		} else {
+			other.groups = append(other.groups, valid[i])
+		}
+	}
+
+	e.limiter.folded.Set(float64(len(other.groups)))
+
+	if len(other.groups) > 0 {
+		result = append(result, other)
+	}
+	return result
+}
+
+// otherGroup sums the stats of folded groups.
+// Its labels from GroupLabels are exported as empty.
+type otherGroup struct {
+	groups []GroupStatistics
+	failed func(member GroupStatistics, err error)
+}
+
+// Collect is required by GroupStatistics, but Exporter calls CollectContext.
+func (g *otherGroup) Collect() Stats {
+	stats, _ := g.CollectContext(context.Background())
+	return stats
+}
+
+// CollectContext sums the stats of folded groups.
+// Failing members are reported and left out from the sum,
+// unless the context is done.
+func (g *otherGroup) CollectContext(ctx context.Context) (Stats, error) {
+	var sum Stats
+	for _, member := range g.groups {
+		stats, err := collectMember(ctx, member)
+		if err != nil {
+			if ctx.Err() != nil {
+				return Stats{}, err
+			}
+			g.failed(member, err)
+			continue
+		}
+		sum.add(stats)
+	}
+	return sum, nil
+}
+
+// collectMember collects a folded group, recovering from a panic in the group.
+func collectMember(ctx context.Context, member GroupStatistics) (stats Stats, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return collectGroup(ctx, member)
+}
+
+// memberFailure logs the failure of a group folded into OtherGroups
+// and counts it in collect_failures_total.
+func (e *Exporter) memberFailure(member GroupStatistics, err error) {
+	labelValues, _ := e.safeLabelValues(member)
+	err = fmt.Errorf("collect group %q folded into %s: %w", labelValues, OtherGroups, err)
+	slog.Error("collectFromGroup", "group", labelValues, "error", err)
+	if e.self != nil {
+		e.self.failure(labelValues)
+	}
+}
+
+// Name returns OtherGroups.
+func (g *otherGroup) Name() string { return OtherGroups }
+
+// Supported returns metric families supported by all folded groups.
+func (g *otherGroup) Supported() Metrics {
+	supported := AllMetrics
+	for _, member := range g.groups {
+		supported &= SupportedBy(member)
+	}
+	return supported
+}
@@ -0,0 +1,122 @@
+package groupcache_exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// go test -count 1 -run '^TestMaxGroups$' ./...
+func TestMaxGroups(t *testing.T) {
+	g1 := &fakeGroup{name: "group1"}
+	g2 := &fakeGroup{name: "group2"}
+	g3 := &fakeGroup{name: "group3", supported: AllMetrics &^ MetricCrosstalkRefusals}
+	g4 := &fakeGroup{name: "group4"}
+	for i, g := range []*fakeGroup{g1, g2, g3, g4} {
+		g.stats.Group.CounterGets = int64(i + 1)
+		g.stats.Group.GaugeGetFromPeersLatencyLower = float64(10 * (i + 1))
+	}
+
+	groups := []GroupStatistics{g1, g2, g3, g4}
+
+	e := NewExporter(Options{
+		ListGroups: func() []GroupStatistics { return groups },
+		MaxGroups:  2,
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(e)
+
+	expected := `
+# HELP groupcache_gets_total Count of cache gets (including from peers)
+# TYPE groupcache_gets_total counter
+groupcache_gets_total{group="__other__"} 7
+groupcache_gets_total{group="group1"} 1
+groupcache_gets_total{group="group2"} 2
+# HELP groupcache_get_from_peers_latency_slowest_milliseconds Represent slowest duration to request value from peers.
+# TYPE groupcache_get_from_peers_latency_slowest_milliseconds gauge
+groupcache_get_from_peers_latency_slowest_milliseconds{group="__other__"} 40
+groupcache_get_from_peers_latency_slowest_milliseconds{group="group1"} 10
+groupcache_get_from_peers_latency_slowest_milliseconds{group="group2"} 20
+# HELP groupcache_exporter_folded_groups Number of groups beyond the limit folded into group __other__
+# TYPE groupcache_exporter_folded_groups gauge
+groupcache_exporter_folded_groups 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"groupcache_gets_total", "groupcache_get_from_peers_latency_slowest_milliseconds",
+		"groupcache_exporter_folded_groups"); err != nil {
+		t.Error(err)
+	}
+
+	if count := testutil.CollectAndCount(e, "groupcache_crosstalk_refusals_total"); count != 2 {
+		t.Errorf("crosstalk: expected 2 series, since group3 does not support it, got %d", count)
+	}
+
+	// new groups listed first do not displace admitted ones
+	groups = []GroupStatistics{g4, g3, g2, g1}
+	if count := testutil.CollectAndCount(e, "groupcache_gets_total"); count != 3 {
+		t.Errorf("expected 3 series, got %d", count)
+	}
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"groupcache_gets_total"); err != nil {
+		t.Errorf("selection should be stable: %v", err)
+	}
+
+	// removed groups release their slots
+	groups = []GroupStatistics{g1, g3, g4}
+	expected = `
+# HELP groupcache_gets_total Count of cache gets (including from peers)
+# TYPE groupcache_gets_total counter
+groupcache_gets_total{group="__other__"} 4
+groupcache_gets_total{group="group1"} 1
+groupcache_gets_total{group="group3"} 3
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"groupcache_gets_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+// go test -count 1 -run '^TestMaxGroupsFailures$' ./...
+func TestMaxGroupsFailures(t *testing.T) {
+	g1 := &fakeGroup{name: "group1"}
+	g1.stats.Group.CounterGets = 1
+	g3 := &fakeGroup{name: "group3"}
+	g3.stats.Group.CounterGets = 3
+
+	e := NewExporter(Options{
+		ListGroups:  listGroups(&panicNameGroup{}, g1, &panicGroup{fakeGroup{name: "group2"}}, g3),
+		MaxGroups:   1,
+		SelfMetrics: true,
+	})
+
+	ch := make(chan prometheus.Metric, 1000)
+	e.Collect(ch)
+	close(ch)
+
+	gets := map[string]float64{}
+	var invalid int
+	for m := range ch {
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			invalid++
+			continue
+		}
+		if strings.Contains(m.Desc().String(), `"groupcache_gets_total"`) {
+			gets[out.GetLabel()[0].GetValue()] = out.GetCounter().GetValue()
+		}
+	}
+
+	if invalid != 1 {
+		t.Errorf("expected 1 failure for the group without name, got %d", invalid)
+	}
+	if gets["group1"] != 1 || gets[OtherGroups] != 3 {
+		t.Errorf("expected group1=1 and %s=3 without failing group2, got %v", OtherGroups, gets)
+	}
+	if value := testutil.ToFloat64(e.self.collectFailures.WithLabelValues("group2")); value != 1 {
+		t.Errorf("expected 1 failure for folded group2, got %v", value)
+	}
+}
@@ -0,0 +1,65 @@
+// Package google instruments peer pickers for google groupcache.
+//
+// HTTPPool registers itself as the peer picker, hence HTTPPool is
+// instrumented with peer.NewTransport. Wrap is meant for custom PeerPicker
+// implementations registered with groupcache.RegisterPeerPicker
+// or groupcache.RegisterPerGroupPeerPicker:
+//
+//	groupcache.RegisterPerGroupPeerPicker(func(groupName string) groupcache.PeerPicker {
+//		return google.Wrap(metrics, newPicker(groupName), peerAddress)
+//	})
+package google
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/groupcache"
+	pb "github.com/golang/groupcache/groupcachepb"
+	"github.com/udhos/groupcache_exporter/peer"
+)
+
+// Wrap returns a PeerPicker that records per-peer request metrics into m.
+// google groupcache peers do not expose their address, hence peerName
+// is required to return a stable name for the peer, like its address.
+// Wrap panics if peerName is nil.
+func Wrap(m *peer.Metrics, picker groupcache.PeerPicker,
+	peerName func(groupcache.ProtoGetter) string) groupcache.PeerPicker {
+	if peerName == nil {
+		panic("google.Wrap: peerName is required")
+	}
+	return &peerPicker{
+		metrics:  m,
+		picker:   picker,
+		peerName: peerName,
+	}
+}
+
+type peerPicker struct {
+	metrics  *peer.Metrics
+	picker   groupcache.PeerPicker
+	peerName func(groupcache.ProtoGetter) string
+}
+
+// PickPeer returns the peer that owns the specific key.
+func (p *peerPicker) PickPeer(key string) (groupcache.ProtoGetter, bool) {
+	g, ok := p.picker.PickPeer(key)
+	if !ok {
+		return g, ok
+	}
+	return &protoGetter{metrics: p.metrics, getter: g, name: p.peerName(g)}, true
+}
+
+type protoGetter struct {
+	metrics *peer.Metrics
+	getter  groupcache.ProtoGetter
+	name    string
+}
+
+// Get requests a value from the peer.
+func (g *protoGetter) Get(ctx context.Context, in *pb.GetRequest, out *pb.GetResponse) error {
+	begin := time.Now()
+	err := g.getter.Get(ctx, in, out)
+	g.metrics.Observe(in.GetGroup(), g.name, "get", time.Since(begin), err)
+	return err
+}
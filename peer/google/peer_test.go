@@ -0,0 +1,66 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/groupcache"
+	pb "github.com/golang/groupcache/groupcachepb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/udhos/groupcache_exporter/peer"
+)
+
+type fakePeer struct{}
+
+func (p *fakePeer) Get(_ context.Context, in *pb.GetRequest, _ *pb.GetResponse) error {
+	if in.GetKey() == "bad" {
+		return errors.New("bad key")
+	}
+	return nil
+}
+
+type fakePicker struct{}
+
+func (p *fakePicker) PickPeer(_ string) (groupcache.ProtoGetter, bool) { return &fakePeer{}, true }
+
+// go test -count 1 -run '^TestWrap$' ./...
+func TestWrap(t *testing.T) {
+
+	registry := prometheus.NewPedanticRegistry()
+
+	peerName := func(groupcache.ProtoGetter) string { return "peer1" }
+
+	picker := Wrap(peer.New(peer.Options{Registerer: registry}), &fakePicker{}, peerName)
+
+	group := "group1"
+	for _, key := range []string{"good", "bad"} {
+		p, _ := picker.PickPeer(key)
+		p.Get(context.TODO(), &pb.GetRequest{Group: &group, Key: &key}, &pb.GetResponse{})
+	}
+
+	expected := `
+# HELP groupcache_peer_request_errors_total Count of requests sent to peers that failed
+# TYPE groupcache_peer_request_errors_total counter
+groupcache_peer_request_errors_total{group="group1",operation="get",peer="peer1"} 1
+# HELP groupcache_peer_requests_total Count of requests sent to peers
+# TYPE groupcache_peer_requests_total counter
+groupcache_peer_requests_total{group="group1",operation="get",peer="peer1"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"groupcache_peer_requests_total", "groupcache_peer_request_errors_total"); err != nil {
+		t.Errorf("peer metrics: %v", err)
+	}
+}
+
+// go test -count 1 -run '^TestWrapRequiresPeerName$' ./...
+func TestWrapRequiresPeerName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for nil peerName")
+		}
+	}()
+	Wrap(peer.New(peer.Options{Registerer: prometheus.NewRegistry()}), &fakePicker{}, nil)
+}
@@ -0,0 +1,88 @@
+// Package mailgun instruments peer pickers for mailgun groupcache.
+//
+// HTTPPool registers itself as the peer picker, hence HTTPPool is
+// instrumented with peer.NewTransport. Wrap is meant for custom PeerPicker
+// implementations registered with groupcache.RegisterPeerPicker or
+// groupcache.RegisterPerGroupPeerPicker:
+//
+//	groupcache.RegisterPeerPicker(func() groupcache.PeerPicker {
+//		return mailgun.Wrap(metrics, newPicker())
+//	})
+package mailgun
+
+import (
+	"context"
+	"time"
+
+	"github.com/mailgun/groupcache/v2"
+	pb "github.com/mailgun/groupcache/v2/groupcachepb"
+	"github.com/udhos/groupcache_exporter/peer"
+)
+
+// Wrap returns a PeerPicker that records per-peer request metrics into m.
+// Peers are labeled after their URL.
+func Wrap(m *peer.Metrics, picker groupcache.PeerPicker) groupcache.PeerPicker {
+	return &peerPicker{metrics: m, picker: picker}
+}
+
+type peerPicker struct {
+	metrics *peer.Metrics
+	picker  groupcache.PeerPicker
+}
+
+// PickPeer returns the peer that owns the specific key.
+func (p *peerPicker) PickPeer(key string) (groupcache.ProtoGetter, bool) {
+	g, ok := p.picker.PickPeer(key)
+	if !ok {
+		return g, ok
+	}
+	return &protoGetter{metrics: p.metrics, getter: g}, true
+}
+
+// GetAll returns all the peers in the group.
+func (p *peerPicker) GetAll() []groupcache.ProtoGetter {
+	all := p.picker.GetAll()
+	result := make([]groupcache.ProtoGetter, 0, len(all))
+	for _, g := range all {
+		result = append(result, &protoGetter{metrics: p.metrics, getter: g})
+	}
+	return result
+}
+
+type protoGetter struct {
+	metrics *peer.Metrics
+	getter  groupcache.ProtoGetter
+}
+
+func (g *protoGetter) observe(groupName, operation string, begin time.Time, err error) {
+	g.metrics.Observe(groupName, g.getter.GetURL(), operation, time.Since(begin), err)
+}
+
+// Get requests a value from the peer.
+func (g *protoGetter) Get(ctx context.Context, in *pb.GetRequest, out *pb.GetResponse) error {
+	begin := time.Now()
+	err := g.getter.Get(ctx, in, out)
+	g.observe(in.GetGroup(), "get", begin, err)
+	return err
+}
+
+// Remove requests the peer to remove a key.
+func (g *protoGetter) Remove(ctx context.Context, in *pb.GetRequest) error {
+	begin := time.Now()
+	err := g.getter.Remove(ctx, in)
+	g.observe(in.GetGroup(), "remove", begin, err)
+	return err
+}
+
+// Set requests the peer to set a key.
+func (g *protoGetter) Set(ctx context.Context, in *pb.SetRequest) error {
+	begin := time.Now()
+	err := g.getter.Set(ctx, in)
+	g.observe(in.GetGroup(), "set", begin, err)
+	return err
+}
+
+// GetURL returns the peer URL.
+func (g *protoGetter) GetURL() string {
+	return g.getter.GetURL()
+}
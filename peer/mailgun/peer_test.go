@@ -0,0 +1,70 @@
+package mailgun
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mailgun/groupcache/v2"
+	pb "github.com/mailgun/groupcache/v2/groupcachepb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/udhos/groupcache_exporter/peer"
+)
+
+type fakePeer struct {
+	url string
+}
+
+func (p *fakePeer) Get(_ context.Context, in *pb.GetRequest, _ *pb.GetResponse) error {
+	if in.GetKey() == "bad" {
+		return errors.New("bad key")
+	}
+	return nil
+}
+
+func (p *fakePeer) Remove(_ context.Context, _ *pb.GetRequest) error { return nil }
+
+func (p *fakePeer) Set(_ context.Context, _ *pb.SetRequest) error { return nil }
+
+func (p *fakePeer) GetURL() string { return p.url }
+
+type fakePicker struct {
+	peer *fakePeer
+}
+
+func (p *fakePicker) PickPeer(_ string) (groupcache.ProtoGetter, bool) { return p.peer, true }
+
+func (p *fakePicker) GetAll() []groupcache.ProtoGetter { return []groupcache.ProtoGetter{p.peer} }
+
+// go test -count 1 -run '^TestWrap$' ./...
+func TestWrap(t *testing.T) {
+
+	registry := prometheus.NewPedanticRegistry()
+
+	picker := Wrap(peer.New(peer.Options{Registerer: registry}), &fakePicker{peer: &fakePeer{url: "http://peer1"}})
+
+	group := "group1"
+	for _, key := range []string{"good", "bad"} {
+		p, _ := picker.PickPeer(key)
+		p.Get(context.TODO(), &pb.GetRequest{Group: &group, Key: &key}, &pb.GetResponse{})
+	}
+	for _, p := range picker.GetAll() {
+		p.Remove(context.TODO(), &pb.GetRequest{Group: &group})
+	}
+
+	expected := `
+# HELP groupcache_peer_request_errors_total Count of requests sent to peers that failed
+# TYPE groupcache_peer_request_errors_total counter
+groupcache_peer_request_errors_total{group="group1",operation="get",peer="http://peer1"} 1
+# HELP groupcache_peer_requests_total Count of requests sent to peers
+# TYPE groupcache_peer_requests_total counter
+groupcache_peer_requests_total{group="group1",operation="get",peer="http://peer1"} 2
+groupcache_peer_requests_total{group="group1",operation="remove",peer="http://peer1"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"groupcache_peer_requests_total", "groupcache_peer_request_errors_total"); err != nil {
+		t.Errorf("peer metrics: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package peer records per-peer request metrics for groupcache.
+//
+// Applications using HTTPPool should install NewTransport through
+// HTTPPool.Transport (google) or HTTPPoolOptions.Transport (mailgun, modernprogram):
+//
+//	rt := peer.NewTransport(metrics, peer.TransportOptions{})
+//	options.Transport = func(context.Context) http.RoundTripper { return rt }
+//
+// Subpackages google, mailgun and modernprogram wrap custom PeerPicker
+// implementations, since HTTPPool registers itself as the peer picker.
+package peer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OtherPeers is the peer label value shared by peers beyond Options.MaxPeers.
+const OtherPeers = "__other__"
+
+// Options define parameters for Metrics.
+type Options struct {
+	// Namespace and Labels should match those given to groupcache_exporter.Options.
+	Namespace string
+	Labels    map[string]string
+
+	// Registerer registers the metrics. If undefined, defaults to prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+
+	// DurationBuckets defines buckets for request duration in seconds.
+	// If undefined, defaults to prometheus.DefBuckets.
+	DurationBuckets []float64
+
+	// MaxPeers caps the number of distinct peer label values.
+	// Peers seen after the cap is reached are reported as OtherPeers.
+	// If undefined, defaults to 100.
+	MaxPeers int
+}
+
+// Metrics holds per-peer request metrics.
+type Metrics struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+
+	maxPeers int
+	mutex    sync.Mutex
+	peers    map[string]struct{}
+}
+
+// New creates Metrics and registers them.
+func New(options Options) *Metrics {
+
+	const subsystem = "groupcache"
+
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	durationBuckets := options.DurationBuckets
+	if len(durationBuckets) == 0 {
+		durationBuckets = prometheus.DefBuckets
+	}
+
+	maxPeers := options.MaxPeers
+	if maxPeers < 1 {
+		maxPeers = 100
+	}
+
+	labelNames := []string{"group", "peer", "operation"}
+
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   options.Namespace,
+				Subsystem:   subsystem,
+				Name:        "peer_requests_total",
+				Help:        "Count of requests sent to peers",
+				ConstLabels: options.Labels,
+			},
+			labelNames,
+		),
+		errors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   options.Namespace,
+				Subsystem:   subsystem,
+				Name:        "peer_request_errors_total",
+				Help:        "Count of requests sent to peers that failed",
+				ConstLabels: options.Labels,
+			},
+			labelNames,
+		),
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   options.Namespace,
+				Subsystem:   subsystem,
+				Name:        "peer_request_duration_seconds",
+				Help:        "Duration of requests sent to peers",
+				ConstLabels: options.Labels,
+				Buckets:     durationBuckets,
+			},
+			labelNames,
+		),
+		maxPeers: maxPeers,
+		peers:    map[string]struct{}{},
+	}
+
+	registerer.MustRegister(m.requests, m.errors, m.duration)
+
+	return m
+}
+
+// Observe records a single request sent to a peer.
+// operation is get, remove or set.
+func (m *Metrics) Observe(groupName, peerName, operation string, elapsed time.Duration, err error) {
+	peerName = m.peerLabel(peerName)
+	m.requests.WithLabelValues(groupName, peerName, operation).Inc()
+	m.duration.WithLabelValues(groupName, peerName, operation).Observe(elapsed.Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(groupName, peerName, operation).Inc()
+	}
+}
+
+// peerLabel keeps the first maxPeers peers seen, folding others into OtherPeers.
+func (m *Metrics) peerLabel(peerName string) string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, found := m.peers[peerName]; found {
+		return peerName
+	}
+	if len(m.peers) >= m.maxPeers {
+		return OtherPeers
+	}
+	m.peers[peerName] = struct{}{}
+	return peerName
+}
@@ -0,0 +1,33 @@
+package peer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// go test -count 1 -run '^TestMaxPeers$' ./...
+func TestMaxPeers(t *testing.T) {
+
+	registry := prometheus.NewPedanticRegistry()
+
+	m := New(Options{Registerer: registry, MaxPeers: 2})
+
+	for _, p := range []string{"peer1", "peer2", "peer3", "peer4", "peer1"} {
+		m.Observe("group1", p, "get", time.Millisecond, nil)
+	}
+
+	expected := `
+# HELP groupcache_peer_requests_total Count of requests sent to peers
+# TYPE groupcache_peer_requests_total counter
+groupcache_peer_requests_total{group="group1",operation="get",peer="__other__"} 2
+groupcache_peer_requests_total{group="group1",operation="get",peer="peer1"} 2
+groupcache_peer_requests_total{group="group1",operation="get",peer="peer2"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "groupcache_peer_requests_total"); err != nil {
+		t.Errorf("peer requests: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package peer
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TransportOptions define parameters for NewTransport.
+type TransportOptions struct {
+	// BasePath is the HTTPPool base path, used to find the group name in request paths.
+	// If undefined, defaults to "/_groupcache/".
+	BasePath string
+
+	// Base performs the requests. If undefined, defaults to http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+// NewTransport returns an http.RoundTripper that records per-peer request metrics
+// into m for requests sent by HTTPPool. Peers are labeled after the request host.
+// Responses other than 200 OK are counted as errors.
+func NewTransport(m *Metrics, options TransportOptions) http.RoundTripper {
+	if options.BasePath == "" {
+		options.BasePath = "/_groupcache/"
+	}
+	if options.Base == nil {
+		options.Base = http.DefaultTransport
+	}
+	return &transport{metrics: m, basePath: options.BasePath, base: options.Base}
+}
+
+type transport struct {
+	metrics  *Metrics
+	basePath string
+	base     http.RoundTripper
+}
+
+// RoundTrip performs the request and records its metrics.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	begin := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	observed := err
+	if err == nil && resp.StatusCode != http.StatusOK {
+		observed = errStatus
+	}
+	t.metrics.Observe(t.groupName(req), req.URL.Host, operation(req.Method),
+		time.Since(begin), observed)
+	return resp, err
+}
+
+// errStatus marks responses other than 200 OK as errors.
+var errStatus = errors.New("unexpected response status")
+
+// groupName extracts the group from request path basePath/group/key.
+func (t *transport) groupName(req *http.Request) string {
+	rest, found := strings.CutPrefix(req.URL.EscapedPath(), t.basePath)
+	if !found {
+		return ""
+	}
+	group, _, _ := strings.Cut(rest, "/")
+	if name, err := url.PathUnescape(group); err == nil {
+		return name
+	}
+	return group
+}
+
+// operation maps the HTTPPool request method to get, remove or set.
+func operation(method string) string {
+	switch method {
+	case http.MethodDelete:
+		return "remove"
+	case http.MethodPut:
+		return "set"
+	}
+	return "get"
+}
@@ -0,0 +1,63 @@
+package peer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// go test -count 1 -run '^TestTransport$' ./...
+func TestTransport(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/bad") {
+			http.Error(w, "bad key", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewPedanticRegistry()
+
+	client := &http.Client{Transport: NewTransport(New(Options{Registerer: registry}), TransportOptions{})}
+
+	for _, r := range []struct{ method, key string }{
+		{http.MethodGet, "good"},
+		{http.MethodGet, "bad"},
+		{http.MethodPut, "good"},
+		{http.MethodDelete, "good"},
+	} {
+		req, err := http.NewRequest(r.method,
+			server.URL+"/_groupcache/"+url.PathEscape("group 1")+"/"+r.key, nil)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", r.method, r.key, err)
+		}
+		resp.Body.Close()
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	expected := fmt.Sprintf(`
+# HELP groupcache_peer_request_errors_total Count of requests sent to peers that failed
+# TYPE groupcache_peer_request_errors_total counter
+groupcache_peer_request_errors_total{group="group 1",operation="get",peer=%[1]q} 1
+# HELP groupcache_peer_requests_total Count of requests sent to peers
+# TYPE groupcache_peer_requests_total counter
+groupcache_peer_requests_total{group="group 1",operation="get",peer=%[1]q} 2
+groupcache_peer_requests_total{group="group 1",operation="remove",peer=%[1]q} 1
+groupcache_peer_requests_total{group="group 1",operation="set",peer=%[1]q} 1
+`, host)
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"groupcache_peer_requests_total", "groupcache_peer_request_errors_total"); err != nil {
+		t.Errorf("peer metrics: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+// Package reporter periodically logs groupcache stats with slog,
+// for environments without a Prometheus scraper.
+//
+//	r := reporter.New(reporter.Options{
+//		ListGroups: func() []groupcache_exporter.GroupStatistics { return modernprogram.ListGroups(workspace) },
+//		Interval:   time.Minute,
+//	})
+//	r.Start()
+//	defer r.Stop() // final flush
+package reporter
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/udhos/groupcache_exporter"
+)
+
+// Options define parameters for Reporter.
+type Options struct {
+	// ListGroups is usually the same function given to groupcache_exporter.Options.
+	ListGroups func() []groupcache_exporter.GroupStatistics
+
+	// Labels are attached to every record, like groupcache_exporter.Options.Labels.
+	Labels map[string]string
+
+	// Interval between reports. If undefined, defaults to one minute.
+	Interval time.Duration
+
+	// Logger receives the records. If undefined, defaults to slog.Default().
+	Logger *slog.Logger
+
+	// Level for the records. If undefined, defaults to slog.LevelInfo.
+	Level slog.Level
+
+	// Message for the records. If undefined, defaults to "groupcache stats".
+	Message string
+}
+
+// Reporter periodically writes one slog record per group
+// with rates, hit ratio and eviction counts since the previous report.
+type Reporter struct {
+	options Options
+	tracker *groupcache_exporter.DeltaTracker
+
+	mutex   sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+	running bool
+}
+
+// New creates Reporter.
+func New(options Options) *Reporter {
+	if options.Interval <= 0 {
+		options.Interval = time.Minute
+	}
+	if options.Logger == nil {
+		options.Logger = slog.Default()
+	}
+	if options.Message == "" {
+		options.Message = "groupcache stats"
+	}
+	return &Reporter{
+		options: options,
+		tracker: groupcache_exporter.NewDeltaTracker(),
+	}
+}
+
+// Start takes a baseline sample and starts reporting on every interval.
+// Calling Start on a running Reporter does nothing.
+func (r *Reporter) Start() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.running {
+		return
+	}
+	r.running = true
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	r.Report() // baseline
+
+	go r.loop(r.stop, r.done)
+}
+
+// Stop stops reporting and flushes a final report covering the time
+// since the previous one. Calling Stop on a stopped Reporter does nothing.
+func (r *Reporter) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.running {
+		return
+	}
+	r.running = false
+	close(r.stop)
+	<-r.done
+
+	r.Report() // final flush
+}
+
+func (r *Reporter) loop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(r.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.Report()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Report logs stats changes since the previous report.
+// Groups seen for the first time only record a baseline.
+// Report is called by the background loop, but it may also be called directly.
+func (r *Reporter) Report() {
+	seen := map[string]struct{}{}
+
+	for _, group := range r.options.ListGroups() {
+		groupLabels := groupcache_exporter.LabelsOf(group)
+		key := groupcache_exporter.GroupKey(group)
+		seen[key] = struct{}{}
+
+		delta, ok := r.tracker.Update(key, group.Collect())
+		if !ok {
+			continue
+		}
+		r.log(group.Name(), groupLabels, groupcache_exporter.SupportedBy(group), delta)
+	}
+
+	for _, key := range r.tracker.Groups() {
+		if _, found := seen[key]; !found {
+			r.tracker.Forget(key)
+		}
+	}
+}
+
+func (r *Reporter) log(groupName string, groupLabels map[string]string,
+	supported groupcache_exporter.Metrics, d groupcache_exporter.StatsDelta) {
+
+	attrs := make([]slog.Attr, 0, 20)
+
+	for _, k := range slices.Sorted(maps.Keys(r.options.Labels)) {
+		attrs = append(attrs, slog.String(k, r.options.Labels[k]))
+	}
+	attrs = append(attrs, slog.String("group", groupName))
+	for _, k := range slices.Sorted(maps.Keys(groupLabels)) {
+		attrs = append(attrs, slog.String(k, groupLabels[k]))
+	}
+
+	g := d.Group
+
+	attrs = append(attrs,
+		slog.Duration("interval", d.Elapsed),
+		slog.Bool("reset", d.Reset),
+	)
+
+	// unsupported fields are left out instead of reported as zero
+	add := func(m groupcache_exporter.Metrics, attr slog.Attr) {
+		if supported.Has(m) {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	add(groupcache_exporter.MetricGets, slog.Float64("gets_per_second", d.Rate(g.Gets)))
+	add(groupcache_exporter.MetricHits, slog.Float64("hits_per_second", d.Rate(g.Hits)))
+	add(groupcache_exporter.MetricLoads, slog.Float64("loads_per_second", d.Rate(g.Loads)))
+	add(groupcache_exporter.MetricPeerLoads, slog.Float64("peer_loads_per_second", d.Rate(g.PeerLoads)))
+	add(groupcache_exporter.MetricPeerErrors, slog.Float64("peer_errors_per_second", d.Rate(g.PeerErrors)))
+	add(groupcache_exporter.MetricLocalLoadsErrs, slog.Float64("local_load_errors_per_second", d.Rate(g.LocalLoadsErrs)))
+	add(groupcache_exporter.MetricServerRequests, slog.Float64("server_requests_per_second", d.Rate(g.ServerRequests)))
+	if g.Gets > 0 {
+		add(groupcache_exporter.MetricGets|groupcache_exporter.MetricHits,
+			slog.Float64("hit_ratio", float64(g.Hits)/float64(g.Gets)))
+	}
+
+	attrs = append(attrs,
+		slog.Group("main", cacheTypeAttrs(d, d.Main, d.Current.Main, supported)...),
+		slog.Group("hot", cacheTypeAttrs(d, d.Hot, d.Current.Hot, supported)...),
+	)
+
+	r.options.Logger.LogAttrs(context.Background(), r.options.Level, r.options.Message, attrs...)
+}
+
+// cacheTypeAttrs returns attributes for supported fields.
+// hit_ratio is left out when there were no gets.
+func cacheTypeAttrs(d groupcache_exporter.StatsDelta, c groupcache_exporter.CacheTypeDelta,
+	curr groupcache_exporter.CacheTypeStats, supported groupcache_exporter.Metrics) []any {
+
+	var attrs []any
+	add := func(m groupcache_exporter.Metrics, attr slog.Attr) {
+		if supported.Has(m) {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	add(groupcache_exporter.MetricCacheGets, slog.Float64("gets_per_second", d.Rate(c.Gets)))
+	if c.Gets > 0 {
+		add(groupcache_exporter.MetricCacheGets|groupcache_exporter.MetricCacheHits,
+			slog.Float64("hit_ratio", float64(c.Hits)/float64(c.Gets)))
+	}
+	add(groupcache_exporter.MetricCacheEvictions, slog.Int64("evictions", c.Evictions))
+	add(groupcache_exporter.MetricCacheEvictionsNonExpired, slog.Int64("evictions_nonexpired", c.EvictionsNonExpired))
+	add(groupcache_exporter.MetricCacheItems, slog.Int64("items", curr.GaugeCacheItems))
+	add(groupcache_exporter.MetricCacheBytes, slog.Int64("bytes", curr.GaugeCacheBytes))
+
+	return attrs
+}
@@ -0,0 +1,106 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/udhos/groupcache_exporter"
+)
+
+type fakeGroup struct {
+	mutex sync.Mutex
+	stats groupcache_exporter.Stats
+}
+
+func (g *fakeGroup) Collect() groupcache_exporter.Stats {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.stats
+}
+
+func (g *fakeGroup) Name() string { return "group1" }
+
+func (g *fakeGroup) add(gets, hits int64) {
+	g.mutex.Lock()
+	g.stats.Group.CounterGets += gets
+	g.stats.Group.CounterHits += hits
+	g.mutex.Unlock()
+}
+
+// go test -count 1 -run '^TestReporterFinalFlush$' ./...
+func TestReporterFinalFlush(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	g := &fakeGroup{}
+
+	r := New(Options{
+		ListGroups: func() []groupcache_exporter.GroupStatistics { return []groupcache_exporter.GroupStatistics{g} },
+		Labels:     map[string]string{"app": "test"},
+		Interval:   time.Hour, // only baseline and final flush
+		Logger:     slog.New(slog.NewJSONHandler(&buf, nil)),
+	})
+
+	r.Start()
+	g.add(10, 4)
+	r.Stop()
+	r.Stop() // harmless
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected exactly one json record: %v: %q", err, buf.String())
+	}
+
+	if record["group"] != "group1" || record["app"] != "test" {
+		t.Errorf("missing labels: %v", record)
+	}
+	if record["hit_ratio"] != 0.4 {
+		t.Errorf("expected hit ratio 0.4, got %v", record["hit_ratio"])
+	}
+	if _, found := record["main"].(map[string]any)["evictions"]; !found {
+		t.Errorf("missing main evictions: %v", record)
+	}
+}
+
+// limitedGroup does not support evictions for non-expired keys.
+type limitedGroup struct{ fakeGroup }
+
+func (g *limitedGroup) Supported() groupcache_exporter.Metrics {
+	return groupcache_exporter.AllMetrics &^ groupcache_exporter.MetricCacheEvictionsNonExpired
+}
+
+// go test -count 1 -run '^TestReporterSupported$' ./...
+func TestReporterSupported(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	g := &limitedGroup{}
+
+	r := New(Options{
+		ListGroups: func() []groupcache_exporter.GroupStatistics { return []groupcache_exporter.GroupStatistics{g} },
+		Logger:     slog.New(slog.NewJSONHandler(&buf, nil)),
+	})
+
+	r.Report()
+	r.Report()
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected exactly one json record: %v: %q", err, buf.String())
+	}
+
+	if _, found := record["hit_ratio"]; found {
+		t.Errorf("hit ratio should be left out without gets: %v", record)
+	}
+	main := record["main"].(map[string]any)
+	if _, found := main["evictions_nonexpired"]; found {
+		t.Errorf("unsupported evictions_nonexpired should be left out: %v", main)
+	}
+	if _, found := main["evictions"]; !found {
+		t.Errorf("missing main evictions: %v", main)
+	}
+}
@@ -0,0 +1,159 @@
+package groupcache_exporter
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// selfMetrics instruments the cost of Exporter.Collect itself.
+type selfMetrics struct {
+	scrapeDuration     prometheus.Gauge
+	listGroupsDuration prometheus.Gauge
+	groups             prometheus.Gauge
+	collectDuration    *prometheus.HistogramVec
+	collectFailures    *prometheus.CounterVec
+	groupLabels        []string
+	pruner             *seriesPruner
+}
+
+// defaultSelfMetricsBuckets spans from 10us to 2.6s, since collecting
+// stats from a group is usually very cheap.
+var defaultSelfMetricsBuckets = prometheus.ExponentialBuckets(0.00001, 4, 10)
+
+func newSelfMetrics(namespace string, labels map[string]string, groupLabels []string,
+	buckets []float64, pruner *seriesPruner, builtin *builtinNames) *selfMetrics {
+
+	const subsystem = "groupcache_exporter"
+
+	if len(buckets) == 0 {
+		buckets = defaultSelfMetricsBuckets
+	}
+
+	return &selfMetrics{
+		groupLabels: groupLabels,
+		pruner:      pruner,
+		scrapeDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        builtin.fqName(namespace, subsystem, "scrape_duration_seconds"),
+			Help:        "Duration of the latest collection of all groups",
+			ConstLabels: labels,
+		}),
+		listGroupsDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        builtin.fqName(namespace, subsystem, "list_groups_duration_seconds"),
+			Help:        "Duration of the latest call to ListGroups",
+			ConstLabels: labels,
+		}),
+		groups: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        builtin.fqName(namespace, subsystem, "groups"),
+			Help:        "Number of groups seen in the latest collection",
+			ConstLabels: labels,
+		}),
+		collectDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        builtin.fqName(namespace, subsystem, "group_collect_duration_seconds"),
+			Help:        "Duration of collecting stats from a group",
+			ConstLabels: labels,
+			Buckets:     buckets,
+		}, groupLabels),
+		collectFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        builtin.fqName(namespace, subsystem, "collect_failures_total"),
+			Help:        "Count of failures collecting stats from a group",
+			ConstLabels: labels,
+		}, groupLabels),
+	}
+}
+
+func (s *selfMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		s.scrapeDuration,
+		s.listGroupsDuration,
+		s.groups,
+		s.collectDuration,
+		s.collectFailures,
+	}
+}
+
+func (s *selfMetrics) describe(ch chan<- *prometheus.Desc) {
+	for _, c := range s.collectors() {
+		c.Describe(ch)
+	}
+}
+
+func (s *selfMetrics) collect(ch chan<- prometheus.Metric) {
+	for _, c := range s.collectors() {
+		c.Collect(ch)
+	}
+}
+
+// observeGroup records the duration of collecting stats from a group.
+func (s *selfMetrics) observeGroup(labelValues []string, elapsed time.Duration) {
+	s.pruner.touch(labelValues)
+	s.collectDuration.WithLabelValues(labelValues...).Observe(elapsed.Seconds())
+}
+
+// failure counts a failed collection from a group. Label values are
+// sanitized since the failure may have been caused by invalid label values.
+func (s *selfMetrics) failure(labelValues []string) {
+	values := make([]string, len(s.groupLabels))
+	for i := range values {
+		if i < len(labelValues) {
+			values[i] = strings.ToValidUTF8(labelValues[i], "\uFFFD")
+		}
+	}
+	s.pruner.touch(values)
+	s.collectFailures.WithLabelValues(values...).Inc()
+}
+
+// seriesPruner deletes per-group series of groups no longer collected,
+// so vectors labeled by group do not grow without bound.
+// Series are tracked by scrape number, hence concurrent scrapes
+// do not prune series touched by each other.
+type seriesPruner struct {
+	mutex  sync.Mutex
+	scrape uint64
+	series map[string]prunedSeries
+}
+
+type prunedSeries struct {
+	labelValues []string
+	scrape      uint64
+}
+
+type labelValuesDeleter interface {
+	DeleteLabelValues(labelValues ...string) bool
+}
+
+func newSeriesPruner() *seriesPruner {
+	return &seriesPruner{series: map[string]prunedSeries{}}
+}
+
+// begin starts a scrape, returning its number.
+func (p *seriesPruner) begin() uint64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.scrape++
+	return p.scrape
+}
+
+// touch marks the series as updated by the latest scrape.
+func (p *seriesPruner) touch(labelValues []string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.series[seriesKey(labelValues)] = prunedSeries{labelValues: labelValues, scrape: p.scrape}
+}
+
+// prune deletes from vecs the series not touched since the scrape began.
+func (p *seriesPruner) prune(scrape uint64, vecs ...labelValuesDeleter) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for key, s := range p.series {
+		if s.scrape >= scrape {
+			continue
+		}
+		for _, v := range vecs {
+			v.DeleteLabelValues(s.labelValues...)
+		}
+		delete(p.series, key)
+	}
+}
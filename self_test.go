@@ -0,0 +1,76 @@
+package groupcache_exporter
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// go test -count 1 -run '^TestSelfMetrics$' ./...
+func TestSelfMetrics(t *testing.T) {
+	e := NewExporter(Options{
+		ListGroups:  listGroups(&fakeGroup{name: "group1"}, &fakeGroup{name: "group2"}),
+		Labels:      map[string]string{"app": "test"},
+		SelfMetrics: true,
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(e)
+
+	if _, err := registry.Gather(); err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+
+	for name, expected := range map[string]int{
+		"groupcache_exporter_scrape_duration_seconds":        1,
+		"groupcache_exporter_list_groups_duration_seconds":   1,
+		"groupcache_exporter_groups":                         1,
+		"groupcache_exporter_group_collect_duration_seconds": 2,
+		"groupcache_exporter_collect_failures_total":         0,
+	} {
+		if count := testutil.CollectAndCount(e, name); count != expected {
+			t.Errorf("%s: expected %d series, got %d", name, expected, count)
+		}
+	}
+
+	if value := testutil.ToFloat64(e.self.groups); value != 2 {
+		t.Errorf("expected 2 groups, got %v", value)
+	}
+}
+
+// go test -count 1 -run '^TestSelfMetricsDisabled$' ./...
+func TestSelfMetricsDisabled(t *testing.T) {
+	e := NewExporter(Options{ListGroups: listGroups(&fakeGroup{name: "group1"})})
+	if count := testutil.CollectAndCount(e, "groupcache_exporter_groups"); count != 0 {
+		t.Errorf("expected no self metrics, got %d series", count)
+	}
+}
+
+// go test -count 1 -run '^TestSelfMetricsPruned$' ./...
+func TestSelfMetricsPruned(t *testing.T) {
+	groups := []GroupStatistics{&fakeGroup{name: "group1"}, &panicGroup{fakeGroup{name: "group2"}}}
+	e := NewExporter(Options{
+		ListGroups:  func() []GroupStatistics { return groups },
+		SelfMetrics: true,
+	})
+
+	e.Collect(make(chan prometheus.Metric, 100))
+
+	if count := testutil.CollectAndCount(e.self.collectDuration); count != 1 {
+		t.Errorf("duration: expected 1 series, got %d", count)
+	}
+	if count := testutil.CollectAndCount(e.self.collectFailures); count != 1 {
+		t.Errorf("failures: expected 1 series, got %d", count)
+	}
+
+	groups = []GroupStatistics{&fakeGroup{name: "group3"}}
+	e.Collect(make(chan prometheus.Metric, 100))
+
+	if count := testutil.CollectAndCount(e.self.collectDuration); count != 1 {
+		t.Errorf("duration: expected 1 series after removal, got %d", count)
+	}
+	if count := testutil.CollectAndCount(e.self.collectFailures); count != 0 {
+		t.Errorf("failures: expected no series after removal, got %d", count)
+	}
+}
@@ -0,0 +1,218 @@
+// Package server records metrics for requests served by the groupcache HTTP pool.
+//
+// Wrap the pool handler in order to feed Metrics:
+//
+//	pool := groupcache.NewHTTPPoolOptsWithWorkspace(workspace, myURL, &groupcache.HTTPPoolOptions{})
+//	metrics := server.New(server.Options{})
+//	serverGroupCache := &http.Server{Addr: groupcachePort, Handler: metrics.Wrap(pool)}
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// OtherGroups is the group label value shared by groups beyond Options.MaxGroups
+// and by requests whose group name could not be parsed.
+const OtherGroups = "__other__"
+
+// OtherMethods is the method label value shared by methods not used by groupcache.
+const OtherMethods = "other"
+
+// Options define parameters for Metrics.
+type Options struct {
+	// Namespace and Labels should match those given to groupcache_exporter.Options.
+	Namespace string
+	Labels    map[string]string
+
+	// Registerer registers the metrics. If undefined, defaults to prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+
+	// BasePath must match HTTPPoolOptions.BasePath.
+	// If undefined, defaults to "/_groupcache/".
+	BasePath string
+
+	// DurationBuckets defines buckets for request duration in seconds.
+	// If undefined, defaults to prometheus.DefBuckets.
+	DurationBuckets []float64
+
+	// SizeBuckets defines buckets for response size in bytes.
+	// If undefined, defaults to exponential buckets from 64 bytes to 16 MiB.
+	SizeBuckets []float64
+
+	// MaxGroups caps the number of distinct group label values, since
+	// group names are taken from untrusted request paths.
+	// Groups seen after the cap is reached are reported as OtherGroups.
+	// If undefined, defaults to 100.
+	MaxGroups int
+}
+
+// Metrics holds metrics for requests served by the groupcache HTTP pool.
+type Metrics struct {
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	responseBytes *prometheus.HistogramVec
+
+	basePath  string
+	maxGroups int
+	mutex     sync.Mutex
+	groups    map[string]struct{}
+}
+
+// New creates Metrics and registers them.
+func New(options Options) *Metrics {
+
+	const subsystem = "groupcache"
+
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	basePath := options.BasePath
+	if basePath == "" {
+		basePath = "/_groupcache/"
+	}
+
+	durationBuckets := options.DurationBuckets
+	if len(durationBuckets) == 0 {
+		durationBuckets = prometheus.DefBuckets
+	}
+
+	sizeBuckets := options.SizeBuckets
+	if len(sizeBuckets) == 0 {
+		sizeBuckets = prometheus.ExponentialBuckets(64, 4, 10)
+	}
+
+	maxGroups := options.MaxGroups
+	if maxGroups < 1 {
+		maxGroups = 100
+	}
+
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   options.Namespace,
+				Subsystem:   subsystem,
+				Name:        "http_server_requests_total",
+				Help:        "Count of peer requests served by the HTTP pool",
+				ConstLabels: options.Labels,
+			},
+			[]string{"group", "method", "code"},
+		),
+		duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   options.Namespace,
+				Subsystem:   subsystem,
+				Name:        "http_server_request_duration_seconds",
+				Help:        "Duration of peer requests served by the HTTP pool",
+				ConstLabels: options.Labels,
+				Buckets:     durationBuckets,
+			},
+			[]string{"group", "method"},
+		),
+		responseBytes: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   options.Namespace,
+				Subsystem:   subsystem,
+				Name:        "http_server_response_bytes",
+				Help:        "Size of responses to peer requests served by the HTTP pool",
+				ConstLabels: options.Labels,
+				Buckets:     sizeBuckets,
+			},
+			[]string{"group"},
+		),
+		basePath:  basePath,
+		maxGroups: maxGroups,
+		groups:    map[string]struct{}{},
+	}
+
+	registerer.MustRegister(m.requests, m.duration, m.responseBytes)
+
+	return m
+}
+
+// Wrap returns a handler that records metrics for requests served by next.
+func (m *Metrics) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		begin := time.Now()
+		next.ServeHTTP(rw, r)
+		elapsed := time.Since(begin)
+
+		groupName := m.groupLabel(r.URL.Path)
+		method := methodLabel(r.Method)
+		code := strconv.Itoa(rw.status)
+
+		m.requests.WithLabelValues(groupName, method, code).Inc()
+		m.duration.WithLabelValues(groupName, method).Observe(elapsed.Seconds())
+		m.responseBytes.WithLabelValues(groupName).Observe(float64(rw.size))
+	})
+}
+
+// methodLabel keeps methods used by groupcache, folding others into OtherMethods,
+// since clients choose the method.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return method
+	}
+	return OtherMethods
+}
+
+// groupLabel parses the group name from path basePath/group/key.
+func (m *Metrics) groupLabel(path string) string {
+	rest, found := strings.CutPrefix(path, m.basePath)
+	if !found {
+		return OtherGroups
+	}
+	groupName, _, found := strings.Cut(rest, "/")
+	if !found || groupName == "" || !utf8.ValidString(groupName) {
+		return OtherGroups
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, found := m.groups[groupName]; found {
+		return groupName
+	}
+	if len(m.groups) >= m.maxGroups {
+		return OtherGroups
+	}
+	m.groups[groupName] = struct{}{}
+	return groupName
+}
+
+// responseWriter records status code and size of the response.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modernprogram/groupcache/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// go test -count 1 -run '^TestWrap$' ./...
+func TestWrap(t *testing.T) {
+
+	workspace := groupcache.NewWorkspace()
+
+	pool := groupcache.NewHTTPPoolOptsWithWorkspace(workspace, "http://127.0.0.1", &groupcache.HTTPPoolOptions{})
+
+	groupcache.NewGroupWithWorkspace(groupcache.Options{
+		Workspace:       workspace,
+		Name:            "files",
+		CacheBytesLimit: 1_000_000,
+		Getter: groupcache.GetterFunc(
+			func(_ context.Context, _ string, dest groupcache.Sink, _ *groupcache.Info) error {
+				return dest.SetString("hello", time.Time{})
+			}),
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+
+	handler := New(Options{Registerer: registry, MaxGroups: 2}).Wrap(pool)
+
+	for _, path := range []string{
+		"/_groupcache/files/key1",
+		"/_groupcache/files/key2",
+		"/_groupcache/missing/key1",
+		"/_groupcache/another/key1",
+		"/_groupcache/bad",
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	for _, method := range []string{"BREW", "PROPFIND"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/_groupcache/files/key1", nil))
+	}
+
+	expected := `
+# HELP groupcache_http_server_requests_total Count of peer requests served by the HTTP pool
+# TYPE groupcache_http_server_requests_total counter
+groupcache_http_server_requests_total{code="200",group="files",method="GET"} 2
+groupcache_http_server_requests_total{code="200",group="files",method="other"} 2
+groupcache_http_server_requests_total{code="400",group="__other__",method="GET"} 1
+groupcache_http_server_requests_total{code="404",group="__other__",method="GET"} 1
+groupcache_http_server_requests_total{code="404",group="missing",method="GET"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "groupcache_http_server_requests_total"); err != nil {
+		t.Errorf("server requests: %v", err)
+	}
+}
@@ -69,3 +69,32 @@ type CacheTypeStats struct {
 	// CounterCacheEvictionsNonExpired represents number of evictions for non-expired keys in the main/hot cache
 	CounterCacheEvictionsNonExpired int64
 }
+
+// add accumulates o into s. Counters and gauges are summed,
+// except GaugeGetFromPeersLatencyLower, which keeps the slowest value.
+func (s *Stats) add(o Stats) {
+	g := &s.Group
+	g.CounterGets += o.Group.CounterGets
+	g.CounterHits += o.Group.CounterHits
+	g.GaugeGetFromPeersLatencyLower = max(g.GaugeGetFromPeersLatencyLower, o.Group.GaugeGetFromPeersLatencyLower)
+	g.CounterPeerLoads += o.Group.CounterPeerLoads
+	g.CounterPeerErrors += o.Group.CounterPeerErrors
+	g.CounterLoads += o.Group.CounterLoads
+	g.CounterLoadsDeduped += o.Group.CounterLoadsDeduped
+	g.CounterLocalLoads += o.Group.CounterLocalLoads
+	g.CounterLocalLoadsErrs += o.Group.CounterLocalLoadsErrs
+	g.CounterServerRequests += o.Group.CounterServerRequests
+	g.CounterCrosstalkRefusals += o.Group.CounterCrosstalkRefusals
+
+	s.Main.add(o.Main)
+	s.Hot.add(o.Hot)
+}
+
+func (s *CacheTypeStats) add(o CacheTypeStats) {
+	s.GaugeCacheItems += o.GaugeCacheItems
+	s.GaugeCacheBytes += o.GaugeCacheBytes
+	s.CounterCacheGets += o.CounterCacheGets
+	s.CounterCacheHits += o.CounterCacheHits
+	s.CounterCacheEvictions += o.CounterCacheEvictions
+	s.CounterCacheEvictionsNonExpired += o.CounterCacheEvictionsNonExpired
+}
@@ -0,0 +1,324 @@
+// Package statsd periodically pushes groupcache metrics to a StatsD or DogStatsD agent over UDP.
+// Counters are sent as deltas since the previous push, gauges as gauges.
+//
+//	s, err := statsd.New(statsd.Options{
+//		Address:    "127.0.0.1:8125",
+//		Flavor:     statsd.DogStatsD,
+//		ListGroups: func() []groupcache_exporter.GroupStatistics { return modernprogram.ListGroups(workspace) },
+//	})
+//	s.Start()
+//	defer s.Stop()
+package statsd
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/udhos/groupcache_exporter"
+)
+
+// Flavor selects the wire format.
+type Flavor int
+
+const (
+	// DogStatsD sends group, type and labels as tags.
+	DogStatsD Flavor = iota
+
+	// StatsD has no tags, hence group and type are embedded in the metric name:
+	// prefix.group.gets, prefix.group.main.cache_gets.
+	StatsD
+)
+
+// Options define parameters for Pusher.
+type Options struct {
+	// Address of the agent, for instance 127.0.0.1:8125.
+	Address string
+
+	// Flavor selects DogStatsD (default) or plain StatsD.
+	Flavor Flavor
+
+	// Prefix for metric names. If undefined, defaults to "groupcache".
+	Prefix string
+
+	// ListGroups is usually the same function given to groupcache_exporter.Options.
+	ListGroups func() []groupcache_exporter.GroupStatistics
+
+	// Labels are sent as tags on every metric, like groupcache_exporter.Options.Labels.
+	// Ignored by plain StatsD.
+	Labels map[string]string
+
+	// Supported restricts the metrics sent. If undefined, defaults to AllMetrics.
+	Supported groupcache_exporter.Metrics
+
+	// Interval between pushes. If undefined, defaults to 10 seconds.
+	Interval time.Duration
+
+	// MaxPacketSize limits the size of UDP packets. If undefined, defaults to 1432 bytes.
+	MaxPacketSize int
+}
+
+// Pusher periodically sends groupcache metrics to a StatsD agent.
+type Pusher struct {
+	options Options
+	conn    net.Conn
+	tracker *groupcache_exporter.DeltaTracker
+
+	mutex   sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+	running bool
+}
+
+// New creates Pusher.
+func New(options Options) (*Pusher, error) {
+	if options.Prefix == "" {
+		options.Prefix = "groupcache"
+	}
+	if options.Supported == 0 {
+		options.Supported = groupcache_exporter.AllMetrics
+	}
+	if options.Interval <= 0 {
+		options.Interval = 10 * time.Second
+	}
+	if options.MaxPacketSize <= 0 {
+		options.MaxPacketSize = 1432
+	}
+
+	conn, err := net.Dial("udp", options.Address)
+	if err != nil {
+		return nil, fmt.Errorf("statsd dial %s: %w", options.Address, err)
+	}
+
+	return &Pusher{
+		options: options,
+		conn:    conn,
+		tracker: groupcache_exporter.NewDeltaTracker(),
+	}, nil
+}
+
+// Start pushes immediately and then on every interval.
+// Calling Start on a running Pusher does nothing.
+func (p *Pusher) Start() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.running {
+		return
+	}
+	p.running = true
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+
+	p.Push()
+
+	go p.loop(p.stop, p.done)
+}
+
+// Stop stops pushing after a final push.
+// Calling Stop on a stopped Pusher does nothing.
+func (p *Pusher) Stop() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.running {
+		return
+	}
+	p.running = false
+	close(p.stop)
+	<-p.done
+
+	p.Push()
+}
+
+// Close releases the connection to the agent.
+func (p *Pusher) Close() error {
+	return p.conn.Close()
+}
+
+func (p *Pusher) loop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(p.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.Push()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Push sends gauges for every group, and counter deltas for groups
+// seen in the previous push.
+// Push is called by the background loop, but it may also be called directly.
+func (p *Pusher) Push() {
+	b := &batch{conn: p.conn, maxSize: p.options.MaxPacketSize}
+
+	seen := map[string]struct{}{}
+
+	for _, group := range p.options.ListGroups() {
+		name := group.Name()
+		groupLabels := groupcache_exporter.LabelsOf(group)
+		key := groupcache_exporter.GroupKey(group)
+		seen[key] = struct{}{}
+		supported := p.options.Supported & groupcache_exporter.SupportedBy(group)
+
+		delta, ok := p.tracker.Update(key, group.Collect())
+		p.pushGroup(b, name, groupLabels, supported, delta, ok)
+	}
+
+	for _, key := range p.tracker.Groups() {
+		if _, found := seen[key]; !found {
+			p.tracker.Forget(key)
+		}
+	}
+
+	b.flush()
+}
+
+func (p *Pusher) pushGroup(b *batch, groupName string, groupLabels map[string]string, supported groupcache_exporter.Metrics,
+	d groupcache_exporter.StatsDelta, withCounters bool) {
+
+	send := func(m groupcache_exporter.Metrics, name, kind string, value string, cacheType string) {
+		if !supported.Has(m) {
+			return
+		}
+		b.add(p.line(name, value, kind, groupName, groupLabels, cacheType))
+	}
+
+	counter := func(m groupcache_exporter.Metrics, name string, value int64, cacheType string) {
+		if withCounters {
+			send(m, name, "c", strconv.FormatInt(value, 10), cacheType)
+		}
+	}
+
+	gauge := func(m groupcache_exporter.Metrics, name string, value int64, cacheType string) {
+		send(m, name, "g", strconv.FormatInt(value, 10), cacheType)
+	}
+
+	g := d.Group
+	counter(groupcache_exporter.MetricGets, "gets", g.Gets, "")
+	counter(groupcache_exporter.MetricHits, "hits", g.Hits, "")
+	counter(groupcache_exporter.MetricPeerLoads, "peer_loads", g.PeerLoads, "")
+	counter(groupcache_exporter.MetricPeerErrors, "peer_errors", g.PeerErrors, "")
+	counter(groupcache_exporter.MetricLoads, "loads", g.Loads, "")
+	counter(groupcache_exporter.MetricLoadsDeduped, "loads_deduped", g.LoadsDeduped, "")
+	counter(groupcache_exporter.MetricLocalLoads, "local_loads", g.LocalLoads, "")
+	counter(groupcache_exporter.MetricLocalLoadsErrs, "local_load_errs", g.LocalLoadsErrs, "")
+	counter(groupcache_exporter.MetricServerRequests, "server_requests", g.ServerRequests, "")
+	counter(groupcache_exporter.MetricCrosstalkRefusals, "crosstalk_refusals", g.CrosstalkRefusals, "")
+	send(groupcache_exporter.MetricGetFromPeersLatencyLower, "get_from_peers_latency_slowest_milliseconds", "g",
+		strconv.FormatFloat(d.Current.Group.GaugeGetFromPeersLatencyLower, 'f', -1, 64), "")
+
+	for _, t := range []struct {
+		cacheType string
+		delta     groupcache_exporter.CacheTypeDelta
+		curr      groupcache_exporter.CacheTypeStats
+	}{
+		{"main", d.Main, d.Current.Main},
+		{"hot", d.Hot, d.Current.Hot},
+	} {
+		gauge(groupcache_exporter.MetricCacheItems, "cache_items", t.curr.GaugeCacheItems, t.cacheType)
+		gauge(groupcache_exporter.MetricCacheBytes, "cache_bytes", t.curr.GaugeCacheBytes, t.cacheType)
+		counter(groupcache_exporter.MetricCacheGets, "cache_gets", t.delta.Gets, t.cacheType)
+		counter(groupcache_exporter.MetricCacheHits, "cache_hits", t.delta.Hits, t.cacheType)
+		counter(groupcache_exporter.MetricCacheEvictions, "cache_evictions", t.delta.Evictions, t.cacheType)
+		counter(groupcache_exporter.MetricCacheEvictionsNonExpired, "cache_evictions_nonexpired", t.delta.EvictionsNonExpired, t.cacheType)
+	}
+}
+
+// line formats a single metric line.
+// Group labels are sent as tags for DogStatsD, or as path
+// components after the group name for StatsD, sorted by label name.
+func (p *Pusher) line(name, value, kind, groupName string, groupLabels map[string]string, cacheType string) string {
+	var sb strings.Builder
+	sb.WriteString(p.options.Prefix)
+	sb.WriteByte('.')
+
+	if p.options.Flavor == StatsD {
+		sb.WriteString(sanitize(groupName))
+		sb.WriteByte('.')
+		for _, k := range slices.Sorted(maps.Keys(groupLabels)) {
+			sb.WriteString(sanitize(groupLabels[k]))
+			sb.WriteByte('.')
+		}
+		if cacheType != "" {
+			sb.WriteString(cacheType)
+			sb.WriteByte('.')
+		}
+	}
+
+	sb.WriteString(name)
+	sb.WriteByte(':')
+	sb.WriteString(value)
+	sb.WriteByte('|')
+	sb.WriteString(kind)
+
+	if p.options.Flavor == DogStatsD {
+		sb.WriteString("|#")
+		for _, k := range slices.Sorted(maps.Keys(p.options.Labels)) {
+			sb.WriteString(sanitize(k))
+			sb.WriteByte(':')
+			sb.WriteString(sanitize(p.options.Labels[k]))
+			sb.WriteByte(',')
+		}
+		sb.WriteString("group:")
+		sb.WriteString(sanitize(groupName))
+		for _, k := range slices.Sorted(maps.Keys(groupLabels)) {
+			sb.WriteByte(',')
+			sb.WriteString(sanitize(k))
+			sb.WriteByte(':')
+			sb.WriteString(sanitize(groupLabels[k]))
+		}
+		if cacheType != "" {
+			sb.WriteString(",type:")
+			sb.WriteString(cacheType)
+		}
+	}
+
+	return sb.String()
+}
+
+// sanitize replaces characters reserved by the StatsD protocol.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', '\n', ' ':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// batch packs lines into packets up to maxSize.
+type batch struct {
+	conn    net.Conn
+	maxSize int
+	buf     []byte
+}
+
+func (b *batch) add(line string) {
+	if len(b.buf) > 0 && len(b.buf)+1+len(line) > b.maxSize {
+		b.flush()
+	}
+	if len(b.buf) > 0 {
+		b.buf = append(b.buf, '\n')
+	}
+	b.buf = append(b.buf, line...)
+}
+
+func (b *batch) flush() {
+	if len(b.buf) == 0 {
+		return
+	}
+	if _, err := b.conn.Write(b.buf); err != nil {
+		slog.Error("statsd push", "error", err)
+	}
+	b.buf = b.buf[:0]
+}
@@ -0,0 +1,177 @@
+package statsd
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/udhos/groupcache_exporter"
+)
+
+type fakeGroup struct {
+	stats groupcache_exporter.Stats
+}
+
+func (g *fakeGroup) Collect() groupcache_exporter.Stats { return g.stats }
+
+func (g *fakeGroup) Name() string { return "group1" }
+
+func (g *fakeGroup) Supported() groupcache_exporter.Metrics {
+	return groupcache_exporter.MetricGets | groupcache_exporter.MetricCacheItems
+}
+
+// receive reads lines from packets until no more arrive.
+func receive(t *testing.T, conn net.PacketConn) []string {
+	t.Helper()
+	var lines []string
+	buf := make([]byte, 65536)
+	for {
+		conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+func listen(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	return conn
+}
+
+// go test -count 1 -run '^TestPushDogStatsD$' ./...
+func TestPushDogStatsD(t *testing.T) {
+
+	conn := listen(t)
+	defer conn.Close()
+
+	g := &fakeGroup{}
+
+	p, err := New(Options{
+		Address:    conn.LocalAddr().String(),
+		ListGroups: func() []groupcache_exporter.GroupStatistics { return []groupcache_exporter.GroupStatistics{g} },
+		Labels:     map[string]string{"app": "test"},
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer p.Close()
+
+	g.stats.Group.CounterGets = 10
+	g.stats.Main.GaugeCacheItems = 3
+	p.Push() // baseline: gauges only
+
+	expected := []string{
+		"groupcache.cache_items:0|g|#app:test,group:group1,type:hot",
+		"groupcache.cache_items:3|g|#app:test,group:group1,type:main",
+	}
+	if lines := receive(t, conn); !slices.Equal(lines, expected) {
+		t.Errorf("baseline: expected %q, got %q", expected, lines)
+	}
+
+	g.stats.Group.CounterGets = 25
+	p.Push()
+
+	expected = []string{
+		"groupcache.cache_items:0|g|#app:test,group:group1,type:hot",
+		"groupcache.cache_items:3|g|#app:test,group:group1,type:main",
+		"groupcache.gets:15|c|#app:test,group:group1",
+	}
+	if lines := receive(t, conn); !slices.Equal(lines, expected) {
+		t.Errorf("delta: expected %q, got %q", expected, lines)
+	}
+}
+
+// go test -count 1 -run '^TestPushStatsD$' ./...
+func TestPushStatsD(t *testing.T) {
+
+	conn := listen(t)
+	defer conn.Close()
+
+	g := &fakeGroup{}
+
+	p, err := New(Options{
+		Address:       conn.LocalAddr().String(),
+		Flavor:        StatsD,
+		Prefix:        "app",
+		ListGroups:    func() []groupcache_exporter.GroupStatistics { return []groupcache_exporter.GroupStatistics{g} },
+		MaxPacketSize: 10, // one line per packet
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer p.Close()
+
+	p.Push()
+	receive(t, conn) // discard baseline
+
+	g.stats.Group.CounterGets = 5
+	p.Push()
+
+	expected := []string{
+		"app.group1.gets:5|c",
+		"app.group1.hot.cache_items:0|g",
+		"app.group1.main.cache_items:0|g",
+	}
+	if lines := receive(t, conn); !slices.Equal(lines, expected) {
+		t.Errorf("expected %q, got %q", expected, lines)
+	}
+}
+
+// workspaceGroup reports label workspace, like the modernprogram Workspaces adapter.
+type workspaceGroup struct {
+	fakeGroup
+	workspace string
+}
+
+func (g *workspaceGroup) GroupLabels() map[string]string {
+	return map[string]string{"workspace": g.workspace}
+}
+
+// go test -count 1 -run '^TestPushGroupLabels$' ./...
+func TestPushGroupLabels(t *testing.T) {
+
+	conn := listen(t)
+	defer conn.Close()
+
+	ws1 := &workspaceGroup{workspace: "ws1"}
+	ws2 := &workspaceGroup{workspace: "ws2"}
+
+	p, err := New(Options{
+		Address: conn.LocalAddr().String(),
+		ListGroups: func() []groupcache_exporter.GroupStatistics {
+			return []groupcache_exporter.GroupStatistics{ws1, ws2}
+		},
+		Supported: groupcache_exporter.MetricGets,
+	})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer p.Close()
+
+	ws1.stats.Group.CounterGets = 10
+	ws2.stats.Group.CounterGets = 100
+	p.Push() // baseline
+	receive(t, conn)
+
+	ws1.stats.Group.CounterGets = 15
+	ws2.stats.Group.CounterGets = 130
+	p.Push()
+
+	expected := []string{
+		"groupcache.gets:30|c|#group:group1,workspace:ws2",
+		"groupcache.gets:5|c|#group:group1,workspace:ws1",
+	}
+	if lines := receive(t, conn); !slices.Equal(lines, expected) {
+		t.Errorf("expected %q, got %q", expected, lines)
+	}
+}
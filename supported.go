@@ -0,0 +1,137 @@
+package groupcache_exporter
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Metrics is a set of metric families exported by Exporter.
+// Each bit represents one field of GroupStats or CacheTypeStats.
+type Metrics uint32
+
+// Metric families.
+const (
+	MetricGets Metrics = 1 << iota
+	MetricHits
+	MetricGetFromPeersLatencyLower
+	MetricPeerLoads
+	MetricPeerErrors
+	MetricLoads
+	MetricLoadsDeduped
+	MetricLocalLoads
+	MetricLocalLoadsErrs
+	MetricServerRequests
+	MetricCrosstalkRefusals
+
+	MetricCacheItems
+	MetricCacheBytes
+	MetricCacheGets
+	MetricCacheHits
+	MetricCacheEvictions
+	MetricCacheEvictionsNonExpired
+
+	// AllMetrics holds every metric family.
+	AllMetrics Metrics = 1<<iota - 1
+)
+
+// metricNames maps each metric family to its name,
+// which is the exported metric name without namespace, subsystem and suffix.
+var metricNames = []struct {
+	metric Metrics
+	name   string
+}{
+	{MetricGets, "gets"},
+	{MetricHits, "hits"},
+	{MetricGetFromPeersLatencyLower, "get_from_peers_latency_slowest"},
+	{MetricPeerLoads, "peer_loads"},
+	{MetricPeerErrors, "peer_errors"},
+	{MetricLoads, "loads"},
+	{MetricLoadsDeduped, "loads_deduped"},
+	{MetricLocalLoads, "local_load"},
+	{MetricLocalLoadsErrs, "local_load_errs"},
+	{MetricServerRequests, "server_requests"},
+	{MetricCrosstalkRefusals, "crosstalk_refusals"},
+	{MetricCacheItems, "cache_items"},
+	{MetricCacheBytes, "cache_bytes"},
+	{MetricCacheGets, "cache_gets"},
+	{MetricCacheHits, "cache_hits"},
+	{MetricCacheEvictions, "cache_evictions"},
+	{MetricCacheEvictionsNonExpired, "cache_evictions_nonexpired"},
+}
+
+// Names returns the names of metric families in the set.
+func (s Metrics) Names() []string {
+	var names []string
+	for _, m := range metricNames {
+		if s.Has(m.metric) {
+			names = append(names, m.name)
+		}
+	}
+	return names
+}
+
+// ParseMetrics returns the set of metric families for the names.
+// Unknown names are reported in unknown.
+func ParseMetrics(names []string) (s Metrics, unknown []string) {
+NAME:
+	for _, name := range names {
+		for _, m := range metricNames {
+			if m.name == name {
+				s |= m.metric
+				continue NAME
+			}
+		}
+		unknown = append(unknown, name)
+	}
+	return s, unknown
+}
+
+// Has reports whether all metrics in m are present in the set.
+func (s Metrics) Has(m Metrics) bool {
+	return s&m == m
+}
+
+// SupportedMetrics is an optional interface for GroupStatistics.
+// An implementation that is unable to fill some fields of Stats
+// should implement it in order to report which metric families
+// it actually supports. Exporter omits unsupported metrics
+// instead of exporting them as zero.
+// A GroupStatistics that does not implement SupportedMetrics
+// is assumed to support AllMetrics.
+type SupportedMetrics interface {
+	// Supported returns the metric families filled by Collect.
+	Supported() Metrics
+}
+
+// SupportedBy returns the metric families supported by the group,
+// either reported by interface SupportedMetrics or AllMetrics.
+func SupportedBy(group GroupStatistics) Metrics {
+	if s, ok := group.(SupportedMetrics); ok {
+		return s.Supported()
+	}
+	return AllMetrics
+}
+
+// LabelsOf returns the labels reported by interface GroupLabels, if implemented.
+func LabelsOf(group GroupStatistics) map[string]string {
+	if gl, ok := group.(GroupLabels); ok {
+		return gl.GroupLabels()
+	}
+	return nil
+}
+
+// GroupKey identifies the group by name and labels, since group names
+// are not unique across workspaces. It is meant as DeltaTracker key.
+func GroupKey(group GroupStatistics) string {
+	labels := LabelsOf(group)
+	var sb strings.Builder
+	sb.WriteString(group.Name())
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		sb.WriteByte(0xff)
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(labels[k])
+	}
+	return sb.String()
+}
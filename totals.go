@@ -0,0 +1,42 @@
+package groupcache_exporter
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TotalGroups is the group label value for totals across groups.
+const TotalGroups = "__total__"
+
+// totals accumulates stats from groups collected concurrently.
+type totals struct {
+	mutex     sync.Mutex
+	stats     Stats
+	supported Metrics
+	groups    int
+}
+
+func newTotals() *totals {
+	return &totals{supported: AllMetrics}
+}
+
+// add accumulates stats from a group.
+// Only metric families supported by every group are kept.
+func (t *totals) add(stats Stats, supported Metrics) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.stats.add(stats)
+	t.supported &= supported
+	t.groups++
+}
+
+// collectTotals sends metrics for group TotalGroups, if any group was collected.
+func (e *Exporter) collectTotals(ch chan<- prometheus.Metric, sum *totals) {
+	sum.mutex.Lock()
+	defer sum.mutex.Unlock()
+	if sum.groups == 0 {
+		return
+	}
+	e.sendGroup(ch, sum.stats, e.labelValuesFor(TotalGroups, nil), sum.supported)
+}
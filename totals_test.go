@@ -0,0 +1,91 @@
+package groupcache_exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// go test -count 1 -run '^TestTotals$' ./...
+func TestTotals(t *testing.T) {
+	g1 := &fakeGroup{name: "group1"}
+	g1.stats.Group.CounterGets = 10
+	g1.stats.Group.GaugeGetFromPeersLatencyLower = 30
+	g1.stats.Main.GaugeCacheBytes = 100
+
+	g2 := &fakeGroup{name: "group2", supported: AllMetrics &^ MetricCrosstalkRefusals}
+	g2.stats.Group.CounterGets = 5
+	g2.stats.Group.GaugeGetFromPeersLatencyLower = 20
+	g2.stats.Main.GaugeCacheBytes = 50
+
+	g3 := &fakeGroup{name: "group3"}
+	g3.stats.Group.CounterGets = 1
+
+	e := NewExporter(Options{
+		ListGroups:     listGroups(g1, g2, g3),
+		Totals:         true,
+		MaxGroups:      1,
+		CollectWorkers: 2,
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(e)
+
+	expected := `
+# HELP groupcache_gets_total Count of cache gets (including from peers)
+# TYPE groupcache_gets_total counter
+groupcache_gets_total{group="__other__"} 6
+groupcache_gets_total{group="__total__"} 16
+groupcache_gets_total{group="group1"} 10
+# HELP groupcache_get_from_peers_latency_slowest_milliseconds Represent slowest duration to request value from peers.
+# TYPE groupcache_get_from_peers_latency_slowest_milliseconds gauge
+groupcache_get_from_peers_latency_slowest_milliseconds{group="__other__"} 20
+groupcache_get_from_peers_latency_slowest_milliseconds{group="__total__"} 30
+groupcache_get_from_peers_latency_slowest_milliseconds{group="group1"} 30
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"groupcache_gets_total", "groupcache_get_from_peers_latency_slowest_milliseconds"); err != nil {
+		t.Error(err)
+	}
+
+	if count := testutil.CollectAndCount(e, "groupcache_crosstalk_refusals_total"); count != 1 {
+		t.Errorf("crosstalk: expected only group1, got %d series", count)
+	}
+}
+
+// go test -count 1 -run '^TestReservedGroups$' ./...
+func TestReservedGroups(t *testing.T) {
+	files := &fakeGroup{name: "files"}
+	files.stats.Group.CounterGets = 1
+
+	e := NewExporter(Options{
+		ListGroups: listGroups(files, &fakeGroup{name: TotalGroups}, &fakeGroup{name: OtherGroups}),
+		Totals:     true,
+		MaxGroups:  10,
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(e)
+
+	families, err := registry.Gather()
+	if err == nil {
+		t.Fatal("expected gather error for reserved groups")
+	}
+	if strings.Count(err.Error(), errReservedGroup.Error()) != 2 {
+		t.Errorf("expected 2 reserved group failures, got: %v", err)
+	}
+	if strings.Contains(err.Error(), "collected before") {
+		t.Errorf("unexpected duplicate series: %v", err)
+	}
+
+	for _, f := range families {
+		if f.GetName() != "groupcache_gets_total" {
+			continue
+		}
+		if len(f.GetMetric()) != 2 {
+			t.Errorf("gets: expected files and totals, got %d series", len(f.GetMetric()))
+		}
+	}
+}